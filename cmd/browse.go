@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/phergul/tfsnap/internal/autosave"
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/inject"
+	"github.com/phergul/tfsnap/internal/lock"
+	"github.com/phergul/tfsnap/internal/tui"
+	"github.com/phergul/tfsnap/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var browseCmd = &cobra.Command{
+	Use:         "browse",
+	Short:       "Browse the provider's resources and inject examples or skeletons",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{lock.Annotation: lock.Shared.String()},
+	PreRun:      autosave.PreRun,
+	Run: func(cmd *cobra.Command, args []string) {
+		runInject(cmd, nil)
+	},
+}
+
+func init() {
+	browseCmd.Flags().StringVarP(&version, "version", "v", "", "Version of the provider")
+	browseCmd.Flags().BoolVarP(&localProvider, "local", "l", false, "Use local binary for the schema")
+	browseCmd.Flags().BoolVar(&requiredOnly, "required-only", false, "Only include required attributes and blocks in skeletons")
+	browseCmd.Flags().BoolVar(&withDocs, "with-docs", false, "Comment each skeleton attribute with its description and flags")
+	browseCmd.Flags().BoolVar(&allAttributes, "all", false, "Also list computed-only attributes as comments in skeletons")
+}
+
+// browseResources lists every resource of the provider schema with its schema
+// as the preview and the example and skeleton one tab away, then injects the
+// chosen resources.
+func browseResources(cmd *cobra.Command, cfg *config.Config, schema *tfjson.ProviderSchema, skeletonOpts inject.SkeletonOptions) error {
+	if len(schema.ResourceSchemas) == 0 {
+		fmt.Println("The provider schema has no resources.")
+		return nil
+	}
+
+	exampleVersion := version
+	if exampleVersion != "" && !strings.HasPrefix(exampleVersion, "v") {
+		exampleVersion = "v" + exampleVersion
+	}
+
+	names := util.SortedKeys(schema.ResourceSchemas)
+	items := make([]tui.Item, len(names))
+	for i, name := range names {
+		resourceSchema := schema.ResourceSchemas[name]
+		items[i] = tui.Item{
+			Label:   name,
+			Content: inject.DescribeResource(name, resourceSchema),
+			Meta:    name,
+			Previews: func() ([]tui.Preview, error) {
+				example := "# no documented example; injecting generates one from the schema\n"
+				examples, err := inject.ResourceExamples(cfg, shortResourceName(cfg, name), exampleVersion)
+				if err != nil {
+					example = fmt.Sprintf("# failed to look up examples: %v\n", err)
+				} else if len(examples) > 0 {
+					var parts []string
+					for _, e := range examples {
+						parts = append(parts, strings.TrimSpace(e.Content))
+					}
+					example = strings.Join(parts, "\n\n") + "\n"
+				}
+				return []tui.Preview{
+					{Title: "example", Content: example, HCL: true},
+					{Title: "skeleton", Content: inject.Skeleton(cfg, resourceSchema, name, skeletonOpts), HCL: true},
+				}, nil
+			},
+		}
+	}
+
+	actions := []tui.Action{
+		{Key: "enter", Label: "inject example", Description: "Inject the registry example", Multi: true},
+		{Key: "S", Label: "inject skeleton", Description: "Inject a skeleton built from the schema", Multi: true},
+		{Key: "d", Label: "inject with dependencies", Description: "Inject the example and the resources it references", Multi: true},
+	}
+
+	title := fmt.Sprintf("%s resources", cfg.Provider.Name)
+	result, err := tui.RunActionSelector(title, items, actions)
+	if err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+	if result == nil {
+		return nil
+	}
+
+	return lock.Exclusively(func() error {
+		for _, item := range result.Items {
+			name := item.Meta.(string)
+			switch {
+			case result.Action == "S" || result.Action == "enter" && skeleton:
+				fmt.Printf("Injecting %s skeleton...\n", name)
+				if err := inject.InjectSkeleton(cfg, schema.ResourceSchemas[name], name, skeletonOpts); err != nil {
+					fmt.Printf("Injection failed: %v\n", err)
+				}
+			default:
+				fmt.Printf("Injecting %s...\n", name)
+				withDependencies := dependency || result.Action == "d"
+				if err := inject.InjectResource(cmd.Context(), cfg, shortResourceName(cfg, name), exampleVersion, withDependencies); err != nil {
+					fmt.Printf("Injection failed: %v\n", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func shortResourceName(cfg *config.Config, name string) string {
+	return strings.TrimPrefix(name, cfg.Provider.Name+"_")
+}
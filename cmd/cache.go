@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/inject"
+	"github.com/phergul/tfsnap/internal/inject/client"
+	"github.com/phergul/tfsnap/internal/lock"
+	"github.com/phergul/tfsnap/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local registry and schema cache",
+}
+
+func init() {
+	cacheCmd.AddCommand(newCacheWarmCmd())
+	cacheCmd.AddCommand(newCacheListCmd())
+	cacheCmd.AddCommand(newCacheClearCmd())
+	cacheCmd.AddCommand(newCachePruneCmd())
+}
+
+func newCacheWarmCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "warm <version1> <version2>...",
+		Short: "Prefetch versions, docs and schemas so they are available offline",
+		Long:  "Prefetch the version list, provider metadata, resource docs and provider schema for each version. Use 'latest' for the newest release.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if cfg == nil {
+				fmt.Println("configuration not found in context; run `tfsnap init` first")
+				return nil
+			}
+			if util.Offline() {
+				return fmt.Errorf("cannot warm the cache in offline mode")
+			}
+
+			versions, err := util.GetAvailableProviderVersions(cfg.Provider.SourceMapping.RegistrySource)
+			if err != nil {
+				return err
+			}
+			if len(versions) == 0 {
+				return fmt.Errorf("no versions published for %s", cfg.Provider.SourceMapping.RegistrySource)
+			}
+
+			for _, version := range args {
+				if version == "latest" {
+					version = versions[0]
+				}
+				if !strings.HasPrefix(version, "v") {
+					version = "v" + version
+				}
+
+				fmt.Printf("Warming cache for %s@%s...\n", cfg.Provider.Name, version)
+				docs, err := client.WarmRegistryCache(cfg, version)
+				if err != nil {
+					fmt.Printf("  failed to fetch docs: %v\n", err)
+				} else {
+					fmt.Printf("  cached %d resource docs\n", docs)
+				}
+
+				if _, err := inject.RetrieveProviderSchema(cmd.Context(), cfg, version, false); err != nil {
+					fmt.Printf("  failed to fetch provider schema: %v\n", err)
+				} else {
+					fmt.Println("  cached provider schema")
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func newCacheListCmd() *cobra.Command {
+	var verbose bool
+	cmd := &cobra.Command{
+		Use:         "list",
+		Short:       "Show cache size and age per cache type",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{lock.Annotation: lock.Shared.String()},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if cfg == nil {
+				fmt.Println("configuration not found in context; run `tfsnap init` first")
+				return nil
+			}
+
+			entries, err := util.ListCacheEntries(cfg.WorkingDirectory)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				fmt.Println("Cache is empty")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			if verbose {
+				fmt.Fprintln(w, "TYPE\tKEY\tSIZE\tLAST USED")
+				for _, e := range entries {
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\n", e.Type, e.Key, formatBytes(e.Size), formatAge(e.ModTime))
+				}
+			} else {
+				type summary struct {
+					count  int
+					size   int64
+					oldest time.Time
+					newest time.Time
+				}
+				summaries := make(map[string]*summary)
+				for _, e := range entries {
+					s, ok := summaries[e.Type]
+					if !ok {
+						s = &summary{oldest: e.ModTime, newest: e.ModTime}
+						summaries[e.Type] = s
+					}
+					s.count++
+					s.size += e.Size
+					if e.ModTime.Before(s.oldest) {
+						s.oldest = e.ModTime
+					}
+					if e.ModTime.After(s.newest) {
+						s.newest = e.ModTime
+					}
+				}
+
+				fmt.Fprintln(w, "TYPE\tENTRIES\tSIZE\tOLDEST\tNEWEST")
+				for _, cacheType := range util.SortedKeys(summaries) {
+					s := summaries[cacheType]
+					fmt.Fprintf(w, "%s\t%d\t%s\t%s ago\t%s ago\n", cacheType, s.count, formatBytes(s.size), formatAge(s.oldest), formatAge(s.newest))
+				}
+			}
+
+			var total int64
+			for _, e := range entries {
+				total += e.Size
+			}
+			fmt.Fprintf(w, "\nTotal: %d entries, %s (limit %s)\n", len(entries), formatBytes(total), formatBytes(cacheMaxSize(cfg)))
+			return w.Flush()
+		},
+	}
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "List every cache entry")
+	return cmd
+}
+
+func newCacheClearCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clear [type...]",
+		Short: "Remove all cache entries, or only those of the given types",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if cfg == nil {
+				fmt.Println("configuration not found in context; run `tfsnap init` first")
+				return nil
+			}
+
+			removed, err := util.ClearCache(cfg.WorkingDirectory, args...)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d cache entries\n", removed)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func newCachePruneCmd() *cobra.Command {
+	var olderThan time.Duration
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove unused cache entries and shrink the cache to its size limit",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := config.FromContext(cmd.Context())
+			if cfg == nil {
+				fmt.Println("configuration not found in context; run `tfsnap init` first")
+				return nil
+			}
+
+			removed, freed, err := util.PruneCache(cfg.WorkingDirectory, olderThan, cacheMaxSize(cfg))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d cache entries, freed %s\n", removed, formatBytes(freed))
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&olderThan, "older-than", 0, "Also remove entries not used within this duration (e.g. 720h)")
+	return cmd
+}
+
+func cacheMaxSize(cfg *config.Config) int64 {
+	if cfg.CacheMaxSizeMB > 0 {
+		return cfg.CacheMaxSizeMB << 20
+	}
+	return util.DefaultCacheMaxSize
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func formatAge(t time.Time) string {
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove stored snapshot files no snapshot references",
+	Long:  "Remove objects in .tfsnap/objects that no snapshot references, such as files left behind by interrupted saves, and report the reclaimed space.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.FromContext(cmd.Context())
+		if cfg == nil {
+			fmt.Println("configuration not found in context; run `tfsnap init` first")
+			return nil
+		}
+
+		removed, freed, err := snapshot.CollectGarbage(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to collect garbage: %w", err)
+		}
+		fmt.Printf("Removed %d unreferenced objects, reclaimed %s\n", removed, formatBytes(freed))
+		return nil
+	},
+}
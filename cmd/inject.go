@@ -5,9 +5,11 @@ import (
 	"log"
 	"strings"
 
+	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/phergul/tfsnap/internal/autosave"
 	"github.com/phergul/tfsnap/internal/config"
 	"github.com/phergul/tfsnap/internal/inject"
+	"github.com/phergul/tfsnap/internal/lock"
 	"github.com/phergul/tfsnap/internal/util"
 	"github.com/spf13/cobra"
 )
@@ -16,78 +18,134 @@ var version string
 var skeleton bool
 var localProvider bool
 var dependency bool
+var requiredOnly bool
+var withDocs bool
+var allAttributes bool
 
 var injectCmd = &cobra.Command{
-	Use:    "inject <resource1>, <resource2>...",
-	Short:  "Manage resources example injections",
-	Args:   cobra.MinimumNArgs(1),
-	PreRun: autosave.PreRun,
-	Run: func(cmd *cobra.Command, args []string) {
-		cfg := config.FromContext(cmd.Context())
-		if cfg == nil {
-			fmt.Println("configuration not found in context; run `tfsnap init` first")
-			return
-		}
+	Use:         "inject [<resource1>, <resource2>...]",
+	Short:       "Manage resources example injections",
+	Long:        "Inject examples or skeletons of the given resources into main.tf. Without resources, browse the provider's resources to pick from.",
+	Annotations: map[string]string{lock.Annotation: lock.Shared.String()},
+	PreRun:      autosave.PreRun,
+	Run:         runInject,
+}
 
-		if !localProvider && version == "" {
-			version = util.GetLatestProviderVersion(cfg)
-		}
-		log.Println("Using provider version:", version)
+func runInject(cmd *cobra.Command, args []string) {
+	cfg := config.FromContext(cmd.Context())
+	if cfg == nil {
+		fmt.Println("configuration not found in context; run `tfsnap init` first")
+		return
+	}
 
-		schema, err := inject.RetrieveProviderSchema(cfg, version, localProvider)
-		if err != nil {
-			fmt.Printf("Injection failed: error retrieving provider schema: %v\n", err)
-			return
-		}
-		if schema == nil {
-			fmt.Println("Injection failed: provider schema is nil")
-			return
+	if requiredOnly || withDocs || allAttributes {
+		skeleton = true
+	}
+	skeletonOpts := inject.SkeletonOptions{
+		RequiredOnly: requiredOnly,
+		WithDocs:     withDocs,
+		All:          allAttributes,
+	}
+
+	if !localProvider && version == "" {
+		version = util.GetLatestProviderVersion(cfg)
+	}
+	log.Println("Using provider version:", version)
+
+	schema, err := inject.RetrieveProviderSchema(cmd.Context(), cfg, version, localProvider)
+	if err != nil {
+		fmt.Printf("Injection failed: error retrieving provider schema: %v\n", err)
+		return
+	}
+	if schema == nil {
+		fmt.Println("Injection failed: provider schema is nil")
+		return
+	}
+
+	if len(args) == 0 {
+		if err := browseResources(cmd, cfg, schema, skeletonOpts); err != nil {
+			fmt.Printf("Injection failed: %v\n", err)
 		}
+		return
+	}
 
-		for _, resourceName := range args {
-			fullProviderResourceName := resourceName
-			if !strings.HasPrefix(resourceName, fmt.Sprintf("%s_", cfg.Provider.Name)) {
-				fullProviderResourceName = fmt.Sprintf("%s_%s", cfg.Provider.Name, resourceName)
-			}
+	providerVersion := version
+	if providerVersion == "" {
+		providerVersion = "latest"
+	}
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
 
-			resourceSchema, valid := inject.ValidateResource(schema, fullProviderResourceName)
-			if !valid {
-				fmt.Printf("Resource '%s' is not valid for provider %s@", args[0], cfg.Provider.Name)
-				if version != "" {
-					fmt.Println(version)
-				} else {
-					fmt.Println("latest")
-				}
-				return
-			}
-			fmt.Printf("Valid resource [%s]. Injecting", resourceName)
+	// named resources are written straight away, so the workspace is held
+	// exclusively for the whole injection
+	err = lock.Exclusively(func() error {
+		injectResources(cmd, cfg, schema, args, providerVersion, skeletonOpts).print()
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Injection failed: %v\n", err)
+	}
+}
 
-			if version != "" && !strings.HasPrefix(version, "v") {
-				version = "v" + version
-			}
+// injectResources injects each named resource, skipping names the provider
+// doesn't have.
+func injectResources(cmd *cobra.Command, cfg *config.Config, schema *tfjson.ProviderSchema, args []string, providerVersion string, skeletonOpts inject.SkeletonOptions) injectSummary {
+	var summary injectSummary
+	var err error
+	for _, resourceName := range args {
+		fullProviderResourceName := resourceName
+		if !strings.HasPrefix(resourceName, fmt.Sprintf("%s_", cfg.Provider.Name)) {
+			fullProviderResourceName = fmt.Sprintf("%s_%s", cfg.Provider.Name, resourceName)
+		}
 
-			if skeleton {
-				fmt.Println(" skeleton...")
-				if err = inject.InjectSkeleton(cfg, resourceSchema, fullProviderResourceName); err != nil {
-					fmt.Printf("Injection failed: %v", err)
-				}
-				return
+		resourceSchema, valid := inject.ValidateResource(schema, fullProviderResourceName)
+		if !valid {
+			fmt.Printf("Resource '%s' is not valid for provider %s@%s; skipping\n", resourceName, cfg.Provider.Name, providerVersion)
+			if suggestions := inject.SuggestResources(schema, fullProviderResourceName); len(suggestions) > 0 {
+				fmt.Printf("  Did you mean: %s?\n", strings.Join(suggestions, ", "))
 			}
+			summary.skipped = append(summary.skipped, resourceName)
+			continue
+		}
+		fmt.Printf("Valid resource [%s]. Injecting", resourceName)
 
-			if after, ok := strings.CutPrefix(resourceName, fmt.Sprintf("%s_", cfg.Provider.Name)); ok {
-				resourceName = after
-			}
+		if skeleton {
+			fmt.Println(" skeleton...")
+			err = inject.InjectSkeleton(cfg, resourceSchema, fullProviderResourceName, skeletonOpts)
+		} else {
 			fmt.Println("...")
-			if err = inject.InjectResource(cfg, resourceName, version, dependency); err != nil {
-				fmt.Printf("Injection failed: %v\n", err)
-			}
+			shortName := strings.TrimPrefix(resourceName, fmt.Sprintf("%s_", cfg.Provider.Name))
+			err = inject.InjectResource(cmd.Context(), cfg, shortName, version, dependency)
 		}
-	},
-	PostRun: func(cmd *cobra.Command, args []string) {
-		if err := inject.CleanupTempDir(); err != nil {
-			log.Println("failed to delete temp dir")
+		if err != nil {
+			fmt.Printf("Injection failed: %v\n", err)
+			summary.failed = append(summary.failed, resourceName)
+			continue
 		}
-	},
+		summary.injected = append(summary.injected, resourceName)
+	}
+
+	return summary
+}
+
+type injectSummary struct {
+	injected []string
+	skipped  []string
+	failed   []string
+}
+
+func (s injectSummary) print() {
+	fmt.Printf("\nSummary: %d injected, %d skipped, %d failed\n", len(s.injected), len(s.skipped), len(s.failed))
+	if len(s.injected) > 0 {
+		fmt.Printf("  ✔ injected: %s\n", strings.Join(s.injected, ", "))
+	}
+	if len(s.skipped) > 0 {
+		fmt.Printf("  - skipped (invalid): %s\n", strings.Join(s.skipped, ", "))
+	}
+	if len(s.failed) > 0 {
+		fmt.Printf("  ✘ failed: %s\n", strings.Join(s.failed, ", "))
+	}
 }
 
 func init() {
@@ -95,4 +153,7 @@ func init() {
 	injectCmd.Flags().BoolVarP(&skeleton, "skeleton", "s", false, "Skeleton version of the resource")
 	injectCmd.Flags().BoolVarP(&localProvider, "local", "l", false, "Use local binary (Only for skeleton)")
 	injectCmd.Flags().BoolVarP(&dependency, "dependencies", "d", false, "Whether to include dependent resources")
+	injectCmd.Flags().BoolVar(&requiredOnly, "required-only", false, "Only include required attributes and blocks in the skeleton")
+	injectCmd.Flags().BoolVar(&withDocs, "with-docs", false, "Comment each skeleton attribute with its description and flags")
+	injectCmd.Flags().BoolVar(&allAttributes, "all", false, "Also list computed-only attributes as comments in the skeleton")
 }
@@ -5,9 +5,13 @@ import (
 
 	"github.com/phergul/tfsnap/internal/autosave"
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/snapshot"
 	"github.com/spf13/cobra"
 )
 
+var mergeRestore bool
+var dryRunRestore bool
+
 var restoreCmd = &cobra.Command{
 	Use:   "restore",
 	Short: "Restore autosaved snapshot",
@@ -19,12 +23,28 @@ var restoreCmd = &cobra.Command{
 			return
 		}
 
-		fmt.Println("Restoring autosave...")
-		err := autosave.RestoreSnapshot(cfg)
+		plan, err := snapshot.PlanLoad(cfg, autosave.AutosaveSnapshotName, mergeRestore)
 		if err != nil {
 			fmt.Println("Error restoring snapshot:", err)
 			return
 		}
-		fmt.Println("Restored")
+		if dryRunRestore {
+			fmt.Printf("Restoring the autosave would make these changes (%s):\n", plan.Summary())
+			fmt.Print(plan.String())
+			return
+		}
+
+		fmt.Println("Restoring autosave...")
+		if err := snapshot.ApplyLoad(cfg, plan); err != nil {
+			fmt.Println("Error restoring snapshot:", err)
+			return
+		}
+		fmt.Print(plan.String())
+		fmt.Printf("Restored (%s)\n", plan.Summary())
 	},
 }
+
+func init() {
+	restoreCmd.Flags().BoolVar(&mergeRestore, "merge", false, "Overlay the autosave onto the working directory instead of replacing it")
+	restoreCmd.Flags().BoolVar(&dryRunRestore, "dry-run", false, "Show which files restoring would add, change or remove")
+}
@@ -1,16 +1,25 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/lock"
+	"github.com/phergul/tfsnap/internal/util"
 	"github.com/spf13/cobra"
 )
 
+var offline bool
+var lockTimeout time.Duration
+
 var rootCmd = &cobra.Command{
 	Use:   "tfsnap",
 	Short: "A CLI tool for managing terraform developer snapshots",
@@ -46,23 +55,72 @@ var rootCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w\ntry running 'tfsnap init' first", err)
 		}
 
+		util.SetCacheMaxSize(cfg.CacheMaxSizeMB << 20)
+		util.ConfigureHTTPCache(cfg.WorkingDirectory, offline)
+		if offline {
+			log.Println("Running in offline mode")
+		}
+
+		mode := lockMode(cmd)
+		commandLine := strings.TrimSpace(cmd.CommandPath() + " " + strings.Join(args, " "))
+		workspaceLock, err = lock.Acquire(filepath.Join(cfg.WorkingDirectory, ".tfsnap"), mode, lockTimeout, commandLine)
+		if err != nil {
+			return err
+		}
+		lock.Hold(workspaceLock)
+		log.Printf("Acquired %s workspace lock", mode)
+
 		cmd.SetContext(config.ToContext(cmd.Context(), &cfg))
 		return nil
 	},
 }
 
+// lockMode returns the mode a command declares with lock.Annotation. Shared
+// commands may run alongside other readers; the interactive ones among them
+// switch to exclusive around each write with lock.Exclusively. Cobra's
+// built-in help command can't carry the annotation, so it is always shared.
+func lockMode(cmd *cobra.Command) lock.Mode {
+	if cmd.Name() == "help" || cmd.Annotations[lock.Annotation] == lock.Shared.String() {
+		return lock.Shared
+	}
+	return lock.Exclusive
+}
+
+var workspaceLock *lock.Lock
+
+func releaseWorkspaceLock() {
+	if err := workspaceLock.Release(); err != nil {
+		log.Printf("failed to release workspace lock: %v", err)
+	}
+}
+
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Serve registry data from the local cache only")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 10*time.Second, "How long to wait for another tfsnap process to release the workspace")
+
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(snapshotCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(injectCmd)
+	rootCmd.AddCommand(browseCmd)
 	rootCmd.AddCommand(restoreCmd)
 	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(templateCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(gcCmd)
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// the first signal cancels the command; restoring the default handlers
+	// lets a second one terminate a command that doesn't stop
+	context.AfterFunc(ctx, stop)
+
+	err := rootCmd.ExecuteContext(ctx)
+	releaseWorkspaceLock()
+	if err != nil || ctx.Err() != nil {
+		stop()
 		os.Exit(1)
 	}
 }
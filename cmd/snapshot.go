@@ -5,12 +5,18 @@ import (
 
 	"github.com/phergul/tfsnap/cmd/snapshot"
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/lock"
 	"github.com/spf13/cobra"
 )
 
+var mergeLoad bool
+var dryRunLoad bool
+var noAutosaveLoad bool
+
 var snapshotCmd = &cobra.Command{
-	Use:   "snapshot",
-	Short: "Manage terraform snapshots",
+	Use:         "snapshot",
+	Short:       "Manage terraform snapshots",
+	Annotations: map[string]string{lock.Annotation: lock.Shared.String()},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := config.FromContext(cmd.Context())
 		if cfg == nil {
@@ -18,10 +24,15 @@ var snapshotCmd = &cobra.Command{
 			return nil
 		}
 
-		return snapshot.Run(cfg)
+		return snapshot.Run(cfg, mergeLoad, dryRunLoad, noAutosaveLoad)
 	},
 }
 
 func init() {
+	snapshotCmd.Flags().BoolVar(&mergeLoad, "merge", false, "Overlay the snapshot onto the working directory instead of replacing it")
+	snapshotCmd.Flags().BoolVar(&dryRunLoad, "dry-run", false, "Show which files loading the snapshot would add, change or remove")
+	snapshotCmd.Flags().BoolVar(&noAutosaveLoad, "no-autosave", false, "Load without autosaving the working directory first")
+
 	snapshotCmd.AddCommand(snapshot.SaveCmd)
+	snapshotCmd.AddCommand(snapshot.ListCmd)
 }
@@ -0,0 +1,153 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/lock"
+	"github.com/phergul/tfsnap/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listFilter  snapshot.Filter
+	listSince   string
+	listUntil   string
+	listSort    string
+	listReverse bool
+	listOutput  string
+)
+
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots, optionally filtered and sorted",
+	Example: `  tfsnap snapshot list --tag bug-123
+  tfsnap snapshot list --resource aws_vpc --build local --sort created -r
+  tfsnap snapshot list --since 7d --output json`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{lock.Annotation: lock.Shared.String()},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.FromContext(cmd.Context())
+		if cfg == nil {
+			fmt.Println("configuration not found in context; run `tfsnap init` first")
+			return nil
+		}
+
+		filter := listFilter
+		if filter.Build != "" && filter.Build != "local" && filter.Build != "registry" {
+			return fmt.Errorf("invalid --build %q: must be local or registry", filter.Build)
+		}
+		var err error
+		if filter.Since, err = parseTime(listSince, false); err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		if filter.Until, err = parseTime(listUntil, true); err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+
+		snapshots, err := snapshot.ListSnapshots(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to load snapshots: %w", err)
+		}
+		snapshots = snapshot.FilterSnapshots(snapshots, filter)
+		if err := snapshot.SortSnapshots(snapshots, listSort, listReverse); err != nil {
+			return err
+		}
+
+		switch listOutput {
+		case "json":
+			if snapshots == nil {
+				snapshots = []*snapshot.Metadata{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(snapshots)
+		case "table":
+			printSnapshotTable(snapshots)
+			return nil
+		default:
+			return fmt.Errorf("invalid --output %q: must be table or json", listOutput)
+		}
+	},
+}
+
+func init() {
+	ListCmd.Flags().StringArrayVarP(&listFilter.Tags, "tag", "t", nil, "Only snapshots with this tag (repeatable; all must match)")
+	ListCmd.Flags().StringVar(&listFilter.ResourceType, "resource", "", "Only snapshots containing this resource type")
+	ListCmd.Flags().StringVar(&listFilter.Version, "version", "", "Only snapshots of this provider version or version prefix")
+	ListCmd.Flags().StringVar(&listFilter.Build, "build", "", "Only local or registry provider builds")
+	ListCmd.Flags().StringVar(&listFilter.Branch, "branch", "", "Only snapshots taken on this provider git branch")
+	ListCmd.Flags().StringVar(&listSince, "since", "", "Only snapshots created at or after this date (2006-01-02, RFC 3339, or an age such as 36h or 7d)")
+	ListCmd.Flags().StringVar(&listUntil, "until", "", "Only snapshots created at or before this date (same formats as --since)")
+	ListCmd.Flags().StringVar(&listSort, "sort", "name", "Sort by "+strings.Join(snapshot.SortFields, ", "))
+	ListCmd.Flags().BoolVarP(&listReverse, "reverse", "r", false, "Reverse the sort order")
+	ListCmd.Flags().StringVarP(&listOutput, "output", "o", "table", "Output format: table or json")
+}
+
+func printSnapshotTable(snapshots []*snapshot.Metadata) {
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots found.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCREATED\tPROVIDER\tRESOURCES\tBRANCH\tTAGS")
+	for _, md := range snapshots {
+		provider, branch := "-", "-"
+		if md.Provider != nil {
+			version := "latest"
+			if md.Provider.DetectedVersion != "" {
+				version = md.Provider.DetectedVersion
+			}
+			if md.Provider.IsLocalBuild {
+				version = "local"
+			}
+			provider = md.Provider.Name + "@" + version
+			if md.Provider.GitInfo != nil && md.Provider.GitInfo.Branch != "" {
+				branch = md.Provider.GitInfo.Branch
+			}
+		}
+		resources := 0
+		if md.ConfigAnalysis != nil {
+			resources = md.ConfigAnalysis.TotalCount
+		}
+		tags := "-"
+		if len(md.Tags) > 0 {
+			tags = strings.Join(md.Tags, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", md.Id, md.CreatedAt.Format("2006-01-02 15:04"), provider, resources, branch, tags)
+	}
+	w.Flush()
+}
+
+// parseTime accepts a date, an RFC 3339 timestamp, or an age relative to now
+// such as 36h or 7d. A bare date used as an upper bound covers the whole day.
+func parseTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q", value)
+}
@@ -2,41 +2,36 @@ package snapshot
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"strings"
 
 	"github.com/phergul/tfsnap/internal/autosave"
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/crypt"
+	"github.com/phergul/tfsnap/internal/lock"
 	"github.com/phergul/tfsnap/internal/snapshot"
 	"github.com/phergul/tfsnap/internal/tui"
 	"github.com/phergul/tfsnap/internal/util"
 )
 
-func Run(cfg *config.Config) error {
-	metadataSlice, err := snapshot.ListSnapshots(cfg)
+// Run opens the snapshot browser. Loading restores the working directory to
+// exactly the snapshot unless merge is set; dryRun only prints the changes.
+// Loading refuses to overwrite files when the autosave fails, unless
+// noAutosave is set. Every other action runs inside the browser, which stays
+// open.
+func Run(cfg *config.Config, merge, dryRun, noAutosave bool) error {
+	items, err := snapshotItems(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to load snapshots: %w", err)
+		return err
 	}
 
-	if len(metadataSlice) == 0 {
+	if len(items) == 0 {
 		fmt.Println("No snapshots found.")
 		return nil
 	}
 
-	items := make([]tui.Item, len(metadataSlice))
-	for i, metadata := range metadataSlice {
-		items[i] = tui.Item{
-			Label:   metadata.Id,
-			Content: formatSnapshotDetails(*metadata),
-			Meta:    metadata,
-		}
-	}
-
-	actions := []tui.Action{
-		{Key: "enter", Label: "load", Description: "Load this snapshot"},
-		{Key: "d", Label: "delete", Description: "Delete this snapshot"},
-	}
-
-	result, err := tui.RunActionSelector("Snapshots", items, actions)
+	result, err := tui.RunActionSelector("Snapshots", items, snapshotActions(cfg, merge, dryRun))
 	if err != nil {
 		return fmt.Errorf("TUI error: %w", err)
 	}
@@ -50,37 +45,261 @@ func Run(cfg *config.Config) error {
 		return fmt.Errorf("invalid snapshot selected")
 	}
 
-	switch result.Action {
-	case "enter":
-		fmt.Println("Creating autosave...")
-		if _, err := snapshot.BuildSnapshot(cfg, autosave.AutosaveSnapshotName, "Autosave snapshot", false, false); err != nil {
-			fmt.Printf("Warning: Autosave failed: %v\n", err)
+	if dryRun {
+		plan, err := snapshot.PlanLoad(cfg, snapshotMeta.Id, merge)
+		if err != nil {
+			return fmt.Errorf("failed to load snapshot: %w", err)
+		}
+		fmt.Printf("Loading snapshot '%s' would make these changes (%s):\n", snapshotMeta.Id, plan.Summary())
+		fmt.Print(plan.String())
+		return nil
+	}
+
+	// the plan is made under the exclusive lock so nothing can change the
+	// working directory between planning and applying it
+	return lock.Exclusively(func() error {
+		plan, err := snapshot.PlanLoad(cfg, snapshotMeta.Id, merge)
+		if err != nil {
+			return fmt.Errorf("failed to load snapshot: %w", err)
+		}
+		if plan.Empty() {
+			fmt.Printf("Working directory already matches snapshot '%s'\n", snapshotMeta.Id)
+			return nil
+		}
+
+		if noAutosave {
+			fmt.Println("Skipping autosave")
+		} else {
+			if snapshotMeta.Id != autosave.AutosaveSnapshotName {
+				fmt.Println("Creating autosave...")
+			}
+			if err := autosave.BeforeLoad(cfg, snapshotMeta.Id, plan); err != nil {
+				if plan.Overwrites() {
+					return fmt.Errorf("autosave failed, so the files loading would change or remove can't be restored; rerun with --no-autosave to load anyway: %w", err)
+				}
+				fmt.Printf("Warning: Autosave failed: %v\n", err)
+			}
 		}
 
 		fmt.Println("Loading snapshot:", snapshotMeta.Id)
-		if err := snapshot.LoadSnapshot(cfg, snapshotMeta.Id); err != nil {
+		if err := snapshot.ApplyLoad(cfg, plan); err != nil {
 			return fmt.Errorf("failed to load snapshot: %w", err)
 		}
-		fmt.Printf("✔ Snapshot '%s' loaded successfully!\n", snapshotMeta.Id)
+		fmt.Print(plan.String())
+		fmt.Printf("✔ Snapshot '%s' loaded successfully! (%s)\n", snapshotMeta.Id, plan.Summary())
+		return nil
+	})
+}
 
-	case "d":
-		if err := snapshot.DeleteSnapshot(cfg, snapshotMeta.Id); err != nil {
-			return fmt.Errorf("failed to delete snapshot: %w", err)
+func snapshotItems(cfg *config.Config) ([]tui.Item, error) {
+	metadataSlice, err := snapshot.ListSnapshots(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshots: %w", err)
+	}
+
+	items := make([]tui.Item, len(metadataSlice))
+	for i, metadata := range metadataSlice {
+		items[i] = tui.Item{
+			Label:    metadata.Id,
+			Content:  formatSnapshotDetails(*metadata),
+			Meta:     metadata,
+			Created:  metadata.CreatedAt,
+			Modified: metadata.ModifiedAt,
+			Previews: func() ([]tui.Preview, error) { return snapshotPreviews(cfg, metadata.Id) },
+		}
+	}
+	return items, nil
+}
+
+// snapshotPreviews lists the snapshot's terraform files as preview panes.
+func snapshotPreviews(cfg *config.Config, name string) ([]tui.Preview, error) {
+	files, err := snapshot.ConfigFiles(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	var previews []tui.Preview
+	for _, rel := range util.SortedKeys(files) {
+		ext := path.Ext(rel)
+		previews = append(previews, tui.Preview{
+			Title:   rel,
+			Content: string(files[rel]),
+			HCL:     ext == ".tf" || ext == ".tfvars" || ext == ".hcl",
+		})
+	}
+	return previews, nil
+}
+
+func snapshotActions(cfg *config.Config, merge, dryRun bool) []tui.Action {
+	// each action reports on the snapshots it touched and reloads the list
+	done := func(status string, err error) tui.Outcome {
+		items, loadErr := snapshotItems(cfg)
+		if err == nil {
+			err = loadErr
+		}
+		if items == nil {
+			items = []tui.Item{}
+		}
+		return tui.Outcome{Status: status, Err: err, Items: items}
+	}
+	meta := func(item *tui.Item) *snapshot.Metadata {
+		return item.Meta.(*snapshot.Metadata)
+	}
+	// actions that write hold the workspace exclusively while they run
+	exclusive := func(run func(items []*tui.Item, input string) tui.Outcome) func([]*tui.Item, string) tui.Outcome {
+		return func(items []*tui.Item, input string) tui.Outcome {
+			var outcome tui.Outcome
+			if err := lock.Exclusively(func() error {
+				outcome = run(items, input)
+				return nil
+			}); err != nil {
+				return tui.Outcome{Err: err}
+			}
+			return outcome
 		}
-		fmt.Printf("✔ Snapshot '%s' deleted successfully!\n", snapshotMeta.Id)
 	}
 
-	return nil
+	return []tui.Action{
+		{
+			// a dry run only prints the plan, so there is nothing to confirm
+			Key: "enter", Label: "load", Description: "Load this snapshot", Confirm: !dryRun,
+			Details: func(items []*tui.Item, _ string) (string, error) {
+				// planning would prompt for the passphrase inside the browser
+				if meta(items[0]).Encrypted && cfg.Encryption.KeyFile == "" && os.Getenv(crypt.PassphraseEnv) == "" {
+					return "Encrypted snapshot: the changes are listed once the passphrase is entered.", nil
+				}
+				plan, err := snapshot.PlanLoad(cfg, meta(items[0]).Id, merge)
+				if err != nil {
+					return "", err
+				}
+				if plan.Empty() {
+					return "Working directory already matches this snapshot.", nil
+				}
+				return plan.Summary() + "\n\n" + plan.String(), nil
+			},
+		},
+		{
+			Key: "d", Label: "delete", Description: "Delete the selected snapshots", Multi: true, Confirm: true,
+			Run: exclusive(func(items []*tui.Item, _ string) tui.Outcome {
+				for i, item := range items {
+					if err := snapshot.DeleteSnapshot(cfg, meta(item).Id); err != nil {
+						return done(fmt.Sprintf("deleted %d snapshot(s)", i), err)
+					}
+				}
+				return done(fmt.Sprintf("✔ deleted %d snapshot(s)", len(items)), nil)
+			}),
+		},
+		{
+			Key: "r", Label: "rename", Description: "Rename this snapshot", Prompt: "New name",
+			Default: func(item *tui.Item) string { return meta(item).Id },
+			Run: exclusive(func(items []*tui.Item, name string) tui.Outcome {
+				id := meta(items[0]).Id
+				if name == id {
+					return tui.Outcome{Status: "name unchanged"}
+				}
+				if err := snapshot.RenameSnapshot(cfg, id, name); err != nil {
+					return tui.Outcome{Err: err}
+				}
+				return done(fmt.Sprintf("✔ renamed '%s' to '%s'", id, name), nil)
+			}),
+		},
+		{
+			Key: "e", Label: "describe", Description: "Edit the description", Prompt: "Description",
+			Default: func(item *tui.Item) string { return meta(item).Description },
+			Run: exclusive(func(items []*tui.Item, description string) tui.Outcome {
+				id := meta(items[0]).Id
+				if _, err := snapshot.SetDescription(cfg, id, description); err != nil {
+					return tui.Outcome{Err: err}
+				}
+				return done(fmt.Sprintf("✔ updated the description of '%s'", id), nil)
+			}),
+		},
+		{
+			Key: "c", Label: "duplicate", Description: "Copy this snapshot under a new name", Prompt: "Name of the copy",
+			Default: func(item *tui.Item) string { return meta(item).Id + "-copy" },
+			Run: exclusive(func(items []*tui.Item, name string) tui.Outcome {
+				id := meta(items[0]).Id
+				if _, err := snapshot.DuplicateSnapshot(cfg, id, name); err != nil {
+					return tui.Outcome{Err: err}
+				}
+				return done(fmt.Sprintf("✔ duplicated '%s' as '%s'", id, name), nil)
+			}),
+		},
+		{
+			Key: "f", Label: "diff", Description: "Diff against the working directory",
+			Run: func(items []*tui.Item, _ string) tui.Outcome {
+				id := meta(items[0]).Id
+				diff, err := snapshot.DiffWorking(cfg, id)
+				if err != nil {
+					return tui.Outcome{Err: err}
+				}
+				if diff == "" {
+					return tui.Outcome{Status: fmt.Sprintf("working directory matches '%s'", id)}
+				}
+				return tui.Outcome{Page: &tui.Page{Title: fmt.Sprintf("%s → working directory", id), Content: tui.HighlightDiff(diff)}}
+			},
+		},
+		{
+			Key: "v", Label: "view", Description: "View the snapshot's terraform files",
+			Run: func(items []*tui.Item, _ string) tui.Outcome {
+				id := meta(items[0]).Id
+				files, err := snapshot.ConfigFiles(cfg, id)
+				if err != nil {
+					return tui.Outcome{Err: err}
+				}
+				var content strings.Builder
+				for _, rel := range util.SortedKeys(files) {
+					fmt.Fprintf(&content, "# ── %s ──\n%s\n", rel, strings.TrimRight(string(files[rel]), "\n"))
+				}
+				return tui.Outcome{Page: &tui.Page{Title: id, Content: tui.HighlightHCL(content.String())}}
+			},
+		},
+		{
+			Key: "x", Label: "export", Description: "Export the selected snapshots", Multi: true, Prompt: "Export to",
+			Default: func(*tui.Item) string { return "tfsnap-export" },
+			Run: func(items []*tui.Item, dest string) tui.Outcome {
+				if dest == "" {
+					return tui.Outcome{Status: "no export directory given"}
+				}
+				for i, item := range items {
+					if _, err := snapshot.ExportSnapshot(cfg, meta(item).Id, dest); err != nil {
+						return tui.Outcome{Status: fmt.Sprintf("exported %d snapshot(s)", i), Err: err}
+					}
+				}
+				return tui.Outcome{Status: fmt.Sprintf("✔ exported %d snapshot(s) to %s", len(items), dest)}
+			},
+		},
+		{
+			Key: "t", Label: "tag", Description: "Tag the selected snapshots", Multi: true, Prompt: "Tags (comma separated)",
+			Run: exclusive(func(items []*tui.Item, input string) tui.Outcome {
+				tags := tui.SplitList(input)
+				if len(tags) == 0 {
+					return tui.Outcome{Status: "no tags given"}
+				}
+				for _, item := range items {
+					if _, err := snapshot.AddTags(cfg, meta(item).Id, tags); err != nil {
+						return done("", err)
+					}
+				}
+				return done(fmt.Sprintf("✔ tagged %d snapshot(s): %s", len(items), strings.Join(tags, ", ")), nil)
+			}),
+		},
+	}
 }
 
 func formatSnapshotDetails(snapshotMeta snapshot.Metadata) string {
 	var details strings.Builder
 	fmt.Fprintf(&details, "Created: %s\n", snapshotMeta.CreatedAt.Format("2006-01-02 15:04:05"))
 	fmt.Fprintf(&details, "Modified: %s\n", snapshotMeta.ModifiedAt.Format("2006-01-02 15:04:05"))
+	if snapshotMeta.Encrypted {
+		fmt.Fprintf(&details, "Encrypted: yes\n")
+	}
 
 	if snapshotMeta.Description != "" {
 		fmt.Fprintf(&details, "\nDescription: %s\n", snapshotMeta.Description)
 	}
+	if len(snapshotMeta.Tags) > 0 {
+		fmt.Fprintf(&details, "Tags: %s\n", strings.Join(snapshotMeta.Tags, ", "))
+	}
 
 	if snapshotMeta.Provider != nil {
 		fmt.Fprintf(&details, "\nProvider: %s@", snapshotMeta.Provider.Name)
@@ -124,5 +343,13 @@ func formatSnapshotDetails(snapshotMeta snapshot.Metadata) string {
 		fmt.Fprintf(&details, "\nBinary included: Yes (%.1f MB)\n", float64(binary.Size)/(1024*1024))
 	}
 
+	if snapshotMeta.State != nil {
+		fmt.Fprintf(&details, "\nState included: serial %d, lineage %s", snapshotMeta.State.Serial, snapshotMeta.State.Lineage)
+		if snapshotMeta.State.Encrypted {
+			fmt.Fprintf(&details, " (encrypted)")
+		}
+		fmt.Fprintf(&details, "\n")
+	}
+
 	return details.String()
 }
@@ -2,8 +2,8 @@ package snapshot
 
 import (
 	"fmt"
-	"log"
 	"path/filepath"
+	"strings"
 
 	"github.com/phergul/tfsnap/internal/autosave"
 	"github.com/phergul/tfsnap/internal/config"
@@ -16,6 +16,10 @@ var (
 	description   string
 	includeBinary bool
 	includeGit    bool
+	includeState  bool
+	encryptState  bool
+	encrypt       bool
+	tags          []string
 	persist       bool
 )
 
@@ -31,31 +35,33 @@ var SaveCmd = &cobra.Command{
 			return
 		}
 
+		opts := snapshot.BuildOptions{
+			IncludeBinary: includeBinary,
+			IncludeGit:    includeGit,
+			IncludeState:  includeState || encryptState,
+			EncryptState:  encryptState,
+			Encrypt:       encrypt || cfg.Encryption.Enabled,
+			Tags:          tags,
+		}
+
 		var metadata *snapshot.Metadata
 		var err error
 		if !util.DirExists(filepath.Join(cfg.SnapshotDirectory, args[0])) {
 			fmt.Println("Saving snapshot:", args[0])
-			metadata, err = snapshot.BuildSnapshot(cfg, args[0], description, includeBinary, includeGit)
+			metadata, err = snapshot.BuildSnapshot(cfg, args[0], description, opts)
 			if err != nil {
 				fmt.Printf("Failed to build snapshot: %v\n", err)
 				return
 			}
 		} else {
 			fmt.Println("Updating existing snapshot:", args[0])
-			metadata, err = snapshot.UpdateSnapshot(cfg, args[0])
+			metadata, err = snapshot.UpdateSnapshot(cfg, args[0], opts)
 			if err != nil {
 				fmt.Printf("Failed to update snapshot: %v\n", err)
 				return
 			}
 		}
 
-		log.Println("Copying terraform files...")
-		err = snapshot.CopyTerraformFiles(cfg, metadata)
-		if err != nil {
-			fmt.Printf("Failed to copy terraform files: %v\n", err)
-			return
-		}
-
 		fmt.Printf("\nSuccessfully saved snapshot: %s\n", args[0])
 		versionInfo := "latest"
 		if metadata.Provider.DetectedVersion != "" {
@@ -76,6 +82,18 @@ var SaveCmd = &cobra.Command{
 				fmt.Printf("Warning: Uncommitted changes detected\n")
 			}
 		}
+		if len(metadata.Tags) > 0 {
+			fmt.Printf("Tags: %s\n", strings.Join(metadata.Tags, ", "))
+		}
+		if metadata.Encrypted {
+			fmt.Println("Encrypted: yes")
+		}
+		if metadata.State != nil {
+			fmt.Printf("State: serial %d, lineage %s\n", metadata.State.Serial, metadata.State.Lineage)
+			if !metadata.State.Encrypted {
+				fmt.Println("Warning: terraform state can contain sensitive values (passwords, keys, tokens) and is stored unencrypted in the snapshot; use --encrypt-state to encrypt it")
+			}
+		}
 
 		if persist {
 			return
@@ -90,5 +108,9 @@ func init() {
 	SaveCmd.Flags().StringVarP(&description, "description", "d", "", "Description of this snapshot")
 	SaveCmd.Flags().BoolVarP(&includeBinary, "include-binary", "b", false, "Whether to include the binary of the provider")
 	SaveCmd.Flags().BoolVarP(&includeGit, "include-git", "g", false, "Whether to include provider repo git info")
+	SaveCmd.Flags().BoolVar(&includeState, "include-state", false, "Whether to include terraform.tfstate and module metadata")
+	SaveCmd.Flags().BoolVar(&encryptState, "encrypt-state", false, "Encrypt the captured state with a passphrase (implies --include-state)")
+	SaveCmd.Flags().BoolVar(&encrypt, "encrypt", false, "Encrypt the terraform files and any captured state")
+	SaveCmd.Flags().StringArrayVarP(&tags, "tag", "t", nil, "Tag the snapshot (repeatable; added to existing tags on update)")
 	SaveCmd.Flags().BoolVarP(&persist, "persist", "p", false, "Whether to persist the saved config")
 }
@@ -4,13 +4,15 @@ import (
 	"fmt"
 
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/lock"
 	"github.com/phergul/tfsnap/internal/template"
 	"github.com/spf13/cobra"
 )
 
 var templateCmd = &cobra.Command{
-	Use:   "template",
-	Short: "Manage resource templates",
+	Use:         "template",
+	Short:       "Manage resource templates",
+	Annotations: map[string]string{lock.Annotation: lock.Shared.String()},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := config.FromContext(cmd.Context())
 		if cfg == nil {
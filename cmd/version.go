@@ -43,7 +43,7 @@ var versionCmd = &cobra.Command{
 			} else {
 				versions, err := util.GetAvailableProviderVersions(cfg.Provider.SourceMapping.RegistrySource)
 				if err != nil {
-					fmt.Println("failed to get available provider versions")
+					fmt.Println("failed to get available provider versions:", err)
 					return
 				}
 				if !slices.ContainsFunc(versions, func(v string) bool {
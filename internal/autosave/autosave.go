@@ -3,8 +3,11 @@ package autosave
 import (
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/crypt"
+	"github.com/phergul/tfsnap/internal/lock"
 	"github.com/phergul/tfsnap/internal/snapshot"
 	"github.com/spf13/cobra"
 )
@@ -27,23 +30,46 @@ func PreRun(cmd *cobra.Command, args []string) {
 	log.Println("AUTOSAVE COMPLETE")
 }
 
-func autosaveSnapshot(cfg *config.Config) {
-	meta, err := snapshot.BuildSnapshot(cfg, AutosaveSnapshotName, "Autosave snapshot", false, false)
-	if err != nil {
-		log.Printf("Autosave failed: %v", err)
+// Options returns the build options for autosaves. Autosaves run without
+// user interaction, so with encryption enabled they need the key file or
+// TFSNAP_PASSPHRASE; without either the autosave is refused rather than
+// written in plaintext.
+func Options(cfg *config.Config) (snapshot.BuildOptions, error) {
+	if !cfg.Encryption.Enabled {
+		return snapshot.BuildOptions{}, nil
 	}
+	if cfg.Encryption.KeyFile == "" && os.Getenv(crypt.PassphraseEnv) == "" {
+		return snapshot.BuildOptions{}, fmt.Errorf("encryption is enabled but no key file or %s is set", crypt.PassphraseEnv)
+	}
+	return snapshot.BuildOptions{Encrypt: true}, nil
+}
 
-	err = snapshot.CopyTerraformFiles(cfg, meta)
+func autosaveSnapshot(cfg *config.Config) {
+	opts, err := Options(cfg)
+	if err == nil {
+		err = lock.Exclusively(func() error {
+			_, err := snapshot.BuildSnapshot(cfg, AutosaveSnapshotName, "Autosave snapshot", opts)
+			return err
+		})
+	}
 	if err != nil {
-		log.Printf("Failed to copy Terraform files: %v", err)
+		log.Printf("Autosave failed: %v", err)
+		fmt.Printf("Warning: Autosave skipped: %v\n", err)
 	}
 }
 
-func RestoreSnapshot(cfg *config.Config) error {
-	err := snapshot.LoadSnapshot(cfg, AutosaveSnapshotName)
+// BeforeLoad autosaves the working directory before plan is applied to it,
+// so `tfsnap restore` can undo the load. Loading the autosave itself skips
+// this: rebuilding it would replace the files the plan is about to read.
+func BeforeLoad(cfg *config.Config, name string, plan *snapshot.LoadPlan) error {
+	if name == AutosaveSnapshotName {
+		return nil
+	}
+	opts, err := Options(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to load snapshot: %w", err)
+		return err
 	}
-
-	return nil
+	opts.IncludeState = plan.ReplacesState()
+	_, err = snapshot.BuildSnapshot(cfg, AutosaveSnapshotName, "Autosave snapshot", opts)
+	return err
 }
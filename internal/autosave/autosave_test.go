@@ -7,67 +7,140 @@ import (
 	"testing"
 
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/crypt"
+	"github.com/phergul/tfsnap/internal/snapshot"
 	"github.com/spf13/cobra"
 )
 
-func TestRestoreSnapshot(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	snapDir := filepath.Join(tmpDir, AutosaveSnapshotName)
-	tfConfigDir := filepath.Join(snapDir, "tfconfig")
-	if err := os.MkdirAll(tfConfigDir, 0755); err != nil {
-		t.Fatalf("Failed to create snapshot dir: %v", err)
+func newWorkspace(t *testing.T) *config.Config {
+	t.Helper()
+	workDir := t.TempDir()
+	providers := `terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+`
+	if err := os.WriteFile(filepath.Join(workDir, "providers.tf"), []byte(providers), 0644); err != nil {
+		t.Fatalf("Failed to write providers.tf: %v", err)
+	}
+	return &config.Config{
+		WorkingDirectory:  workDir,
+		SnapshotDirectory: filepath.Join(workDir, ".tfsnap", "snapshots"),
 	}
+}
+
+func TestBeforeLoadKeepsAutosave(t *testing.T) {
+	cfg := newWorkspace(t)
 
-	testTfFile := filepath.Join(tfConfigDir, "main.tf")
-	tfContent := `resource "aws_instance" "test" { }`
-	if err := os.WriteFile(testTfFile, []byte(tfContent), 0644); err != nil {
-		t.Fatalf("Failed to create test tf file: %v", err)
+	mainTf := filepath.Join(cfg.WorkingDirectory, "main.tf")
+	if err := os.WriteFile(mainTf, []byte(`variable "saved" {}`), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	if _, err := snapshot.BuildSnapshot(cfg, AutosaveSnapshotName, "Autosave snapshot", snapshot.BuildOptions{}); err != nil {
+		t.Fatalf("Failed to build autosave: %v", err)
+	}
+	if err := os.WriteFile(mainTf, []byte(`variable "edited" {}`), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
 	}
 
-	workDir := filepath.Join(tmpDir, "work")
-	if err := os.MkdirAll(workDir, 0755); err != nil {
-		t.Fatalf("Failed to create work dir: %v", err)
+	plan, err := snapshot.PlanLoad(cfg, AutosaveSnapshotName, false)
+	if err != nil {
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+	if err := BeforeLoad(cfg, AutosaveSnapshotName, plan); err != nil {
+		t.Fatalf("BeforeLoad failed: %v", err)
+	}
+	if err := snapshot.ApplyLoad(cfg, plan); err != nil {
+		t.Fatalf("ApplyLoad failed: %v", err)
 	}
 
-	cfg := &config.Config{
-		SnapshotDirectory: tmpDir,
-		WorkingDirectory:  workDir,
+	data, err := os.ReadFile(mainTf)
+	if err != nil || string(data) != `variable "saved" {}` {
+		t.Errorf("Expected main.tf from autosave, got %q (%v)", data, err)
 	}
 
-	err := RestoreSnapshot(cfg)
+	// the autosave must still hold the loaded content
+	again, err := snapshot.PlanLoad(cfg, AutosaveSnapshotName, false)
 	if err != nil {
-		t.Fatalf("RestoreSnapshot failed: %v", err)
+		t.Fatalf("PlanLoad failed after load: %v", err)
 	}
+	if !again.Empty() {
+		t.Errorf("Expected autosave to match working directory, got %s", again.Summary())
+	}
+}
+
+func TestBeforeLoadSavesWorkingDirectory(t *testing.T) {
+	cfg := newWorkspace(t)
 
-	// Validate terraform files were restored
-	restoredTfFile := filepath.Join(workDir, "main.tf")
-	if _, err := os.Stat(restoredTfFile); os.IsNotExist(err) {
-		t.Error("Terraform file was not restored to working directory")
+	mainTf := filepath.Join(cfg.WorkingDirectory, "main.tf")
+	if err := os.WriteFile(mainTf, []byte(`variable "snapshot" {}`), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	if _, err := snapshot.BuildSnapshot(cfg, "snap", "", snapshot.BuildOptions{}); err != nil {
+		t.Fatalf("Failed to build snapshot: %v", err)
+	}
+	if err := os.WriteFile(mainTf, []byte(`variable "current" {}`), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
 	}
 
-	// Validate content was restored correctly
-	restored, err := os.ReadFile(restoredTfFile)
+	plan, err := snapshot.PlanLoad(cfg, "snap", false)
 	if err != nil {
-		t.Fatalf("Failed to read restored file: %v", err)
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+	if err := BeforeLoad(cfg, "snap", plan); err != nil {
+		t.Fatalf("BeforeLoad failed: %v", err)
+	}
+	if err := snapshot.ApplyLoad(cfg, plan); err != nil {
+		t.Fatalf("ApplyLoad failed: %v", err)
 	}
 
-	if string(restored) != tfContent {
-		t.Errorf("Restored content mismatch: expected %q, got %q", tfContent, string(restored))
+	files, err := snapshot.ConfigFiles(cfg, AutosaveSnapshotName)
+	if err != nil {
+		t.Fatalf("Failed to read autosave: %v", err)
+	}
+	if string(files["main.tf"]) != `variable "current" {}` {
+		t.Errorf("Expected autosave to hold the pre-load main.tf, got %q", files["main.tf"])
 	}
 }
 
-func TestRestoreSnapshotNotFound(t *testing.T) {
-	tmpDir := t.TempDir()
+func TestOptionsEncryption(t *testing.T) {
+	t.Setenv(crypt.PassphraseEnv, "")
+	cfg := &config.Config{}
+
+	if opts, err := Options(cfg); err != nil || opts.Encrypt {
+		t.Errorf("Expected plaintext autosaves without encryption, got %+v (%v)", opts, err)
+	}
 
-	cfg := &config.Config{
-		SnapshotDirectory: tmpDir,
-		WorkingDirectory:  tmpDir,
+	cfg.Encryption.Enabled = true
+	if _, err := Options(cfg); err == nil {
+		t.Error("Expected an error when encryption has no non-interactive key")
 	}
 
-	err := RestoreSnapshot(cfg)
-	if err == nil {
-		t.Error("RestoreSnapshot should return error for nonexistent snapshot")
+	t.Setenv(crypt.PassphraseEnv, "secret")
+	if opts, err := Options(cfg); err != nil || !opts.Encrypt {
+		t.Errorf("Expected encryption with %s set, got %+v (%v)", crypt.PassphraseEnv, opts, err)
+	}
+
+	t.Setenv(crypt.PassphraseEnv, "")
+	cfg.Encryption.KeyFile = "key"
+	if opts, err := Options(cfg); err != nil || !opts.Encrypt {
+		t.Errorf("Expected encryption with a key file, got %+v (%v)", opts, err)
+	}
+}
+
+func TestBeforeLoadRefusesPlaintext(t *testing.T) {
+	t.Setenv(crypt.PassphraseEnv, "")
+	cfg := newWorkspace(t)
+	cfg.Encryption.Enabled = true
+
+	if err := BeforeLoad(cfg, "snap", &snapshot.LoadPlan{}); err == nil {
+		t.Fatal("Expected BeforeLoad to refuse an unencrypted autosave")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.SnapshotDirectory, AutosaveSnapshotName)); !os.IsNotExist(err) {
+		t.Error("Expected no autosave to be written")
 	}
 }
 
@@ -20,13 +20,31 @@ type Provider struct {
 	SourceMapping     SourceMapping `yaml:"source_mappings"`
 }
 
+// FilePatterns adds to or removes from the files captured in snapshots.
+type FilePatterns struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Encryption controls snapshot encryption. KeyFile holds the passphrase so
+// it does not have to be entered; relative paths are resolved against the
+// working directory.
+type Encryption struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	KeyFile string `yaml:"key_file,omitempty"`
+}
+
 type Config struct {
-	ConfigPath        string   `yaml:"config_path"`
-	WorkingDirectory  string   `yaml:"working_directory"`
-	Provider          Provider `yaml:"provider"`
-	SnapshotDirectory string   `yaml:"snapshot_directory"`
-	WorkingStrategy   string   `yaml:"working_strategy"`
-	ExampleClientType string   `yaml:"example_client_type"`
+	ConfigPath        string       `yaml:"config_path"`
+	WorkingDirectory  string       `yaml:"working_directory"`
+	Provider          Provider     `yaml:"provider"`
+	SnapshotDirectory string       `yaml:"snapshot_directory"`
+	WorkingStrategy   string       `yaml:"working_strategy"`
+	ExampleClientType string       `yaml:"example_client_type"`
+	CLI               string       `yaml:"cli,omitempty"`
+	CacheMaxSizeMB    int64        `yaml:"cache_max_size_mb,omitempty"`
+	Files             FilePatterns `yaml:"files,omitempty"`
+	Encryption        Encryption   `yaml:"encryption,omitempty"`
 }
 
 func (c *Config) WriteConfig() error {
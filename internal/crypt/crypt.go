@@ -0,0 +1,194 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// PassphraseEnv supplies the passphrase without prompting, e.g. in scripts.
+const PassphraseEnv = "TFSNAP_PASSPHRASE"
+
+const (
+	saltSize   = 16
+	keySize    = 32
+	iterations = 600_000
+)
+
+// magic prefixes every encrypted file: magic | salt | nonce | ciphertext.
+var magic = []byte("TFSNAPENC1")
+
+var ErrNotEncrypted = errors.New("data is not encrypted by tfsnap")
+
+// Cipher encrypts with AES-256-GCM using a key derived from a passphrase with
+// PBKDF2-SHA256. One salt is used for everything a Cipher encrypts so the key
+// is derived once per snapshot; keys for other salts are derived on demand
+// when decrypting.
+type Cipher struct {
+	passphrase string
+	salt       []byte
+	derived    map[string]cipher.AEAD
+}
+
+func New(passphrase string) (*Cipher, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	return &Cipher{
+		passphrase: passphrase,
+		salt:       salt,
+		derived:    make(map[string]cipher.AEAD),
+	}, nil
+}
+
+func (c *Cipher) aead(salt []byte) (cipher.AEAD, error) {
+	if aead, ok := c.derived[string(salt)]; ok {
+		return aead, nil
+	}
+
+	key, err := pbkdf2.Key(sha256.New, c.passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	c.derived[string(salt)] = aead
+	return aead, nil
+}
+
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	aead, err := c.aead(c.salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(magic)+saltSize+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, c.salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, magic), nil
+}
+
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrNotEncrypted
+	}
+	data = data[len(magic):]
+	if len(data) < saltSize {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+
+	salt, data := data[:saltSize], data[saltSize:]
+	aead, err := c.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, magic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: wrong passphrase or corrupted data")
+	}
+	return plaintext, nil
+}
+
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+func (c *Cipher) EncryptFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	encrypted, err := c.Encrypt(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, encrypted, 0600)
+}
+
+// ReadFile returns the plaintext of a file that may or may not be encrypted.
+func (c *Cipher) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !IsEncrypted(data) {
+		return data, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%s is encrypted; a passphrase is required", path)
+	}
+	return c.Decrypt(data)
+}
+
+// ReadKeyFile returns the passphrase stored in a key file. Surrounding
+// whitespace is ignored so the file can end with a newline.
+func ReadKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+	passphrase := strings.TrimSpace(string(data))
+	if passphrase == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return passphrase, nil
+}
+
+// PromptPassphrase reads the passphrase from TFSNAP_PASSPHRASE or asks for it.
+// When confirm is set the passphrase has to be entered twice.
+func PromptPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	prompt := promptui.Prompt{Label: "Snapshot passphrase", Mask: '*'}
+	passphrase, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+
+	if confirm {
+		prompt = promptui.Prompt{Label: "Confirm passphrase", Mask: '*'}
+		again, err := prompt.Run()
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,63 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	c, err := New("secret")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	plaintext := []byte(`password = "hunter2"`)
+	encrypted, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !IsEncrypted(encrypted) || bytes.Contains(encrypted, plaintext) {
+		t.Fatalf("Expected encrypted output, got %q", encrypted)
+	}
+
+	// a fresh cipher with the same passphrase derives the key from the stored salt
+	other, err := New("secret")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	decrypted, err := other.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted)
+	}
+}
+
+func TestDecryptWrongPassphrase(t *testing.T) {
+	c, _ := New("secret")
+	encrypted, err := c.Encrypt([]byte("data"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	wrong, _ := New("other")
+	if _, err := wrong.Decrypt(encrypted); err == nil {
+		t.Error("Expected an error decrypting with the wrong passphrase")
+	}
+	if _, err := wrong.Decrypt([]byte("plain")); err != ErrNotEncrypted {
+		t.Errorf("Expected ErrNotEncrypted, got %v", err)
+	}
+}
+
+func TestPromptPassphraseFromEnv(t *testing.T) {
+	t.Setenv(PassphraseEnv, "from-env")
+
+	passphrase, err := PromptPassphrase(true)
+	if err != nil {
+		t.Fatalf("PromptPassphrase failed: %v", err)
+	}
+	if passphrase != "from-env" {
+		t.Errorf("Expected passphrase from environment, got %q", passphrase)
+	}
+}
@@ -0,0 +1,107 @@
+package inject
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/inject/client"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+// DescribeResource summarises a resource schema for the resource browser:
+// every attribute with its type and whether it is required, optional or
+// computed, followed by the nested blocks.
+func DescribeResource(resourceType string, schema *tfjson.Schema) string {
+	var out strings.Builder
+	out.WriteString(resourceType + "\n")
+	if schema == nil || schema.Block == nil {
+		return out.String()
+	}
+	if schema.Block.Description != "" {
+		out.WriteString("\n" + strings.TrimSpace(schema.Block.Description) + "\n")
+	}
+	describeBlock(&out, schema.Block, 0)
+	return out.String()
+}
+
+func describeBlock(out *strings.Builder, block *tfjson.SchemaBlock, indent int) {
+	prefix := strings.Repeat("  ", indent)
+
+	if len(block.Attributes) > 0 {
+		fmt.Fprintf(out, "\n%sAttributes:\n", prefix)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, key := range util.SortedKeys(block.Attributes) {
+			attr := block.Attributes[key]
+			line := fmt.Sprintf("%s  %s\t%s\t%s", prefix, key, attributeTypeName(attr), attributeUsage(attr))
+			if attr.Deprecated {
+				line += " (deprecated)"
+			}
+			fmt.Fprintln(w, line)
+		}
+		w.Flush()
+	}
+
+	for _, name := range util.SortedKeys(block.NestedBlocks) {
+		nested := block.NestedBlocks[name]
+		fmt.Fprintf(out, "\n%sBlock %s (%s%s):\n", prefix, name, nested.NestingMode, blockLimits(nested))
+		if nested.Block != nil {
+			describeBlock(out, nested.Block, indent+1)
+		}
+	}
+}
+
+func attributeUsage(attr *tfjson.SchemaAttribute) string {
+	switch {
+	case attr.Required:
+		return "required"
+	case attr.Optional && attr.Computed:
+		return "optional, computed"
+	case attr.Optional:
+		return "optional"
+	default:
+		return "computed"
+	}
+}
+
+func blockLimits(nested *tfjson.SchemaBlockType) string {
+	switch {
+	case nested.MinItems > 0 && nested.MaxItems > 0:
+		return fmt.Sprintf(", %d-%d", nested.MinItems, nested.MaxItems)
+	case nested.MinItems > 0:
+		return fmt.Sprintf(", at least %d", nested.MinItems)
+	case nested.MaxItems > 0:
+		return fmt.Sprintf(", at most %d", nested.MaxItems)
+	}
+	return ""
+}
+
+// ResourceExamples looks up the documented examples of a resource without
+// prompting or falling back to a generated example, for previews.
+func ResourceExamples(cfg *config.Config, resourceType, version string) ([]client.ExampleResult, error) {
+	clientType := cfg.ExampleClientType
+	if clientType == "" {
+		clientType = DefaultClient
+	}
+
+	providerVersion := version
+	if clientType != client.LocalClientName && version == "" {
+		providerVersion = util.GetLatestProviderVersion(cfg)
+	}
+
+	examplesClient, err := client.New(clientType, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create examples client: %w", err)
+	}
+	return examplesClient.GetExamples(providerVersion, resourceType)
+}
+
+// Skeleton renders the skeleton InjectSkeleton would write, without writing
+// it.
+func Skeleton(cfg *config.Config, schema *tfjson.Schema, resourceType string, opts SkeletonOptions) string {
+	rules, _ := LoadSampleRules(cfg.WorkingDirectory)
+	opts.SampleRules = append(opts.SampleRules, rules...)
+	return buildSkeleton(schema, resourceType, opts)
+}
@@ -0,0 +1,48 @@
+package inject
+
+import (
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDescribeResource(t *testing.T) {
+	schema := &tfjson.Schema{
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"id":         {AttributeType: cty.String, Computed: true},
+				"cidr_block": {AttributeType: cty.String, Required: true},
+				"tags":       {AttributeType: cty.Map(cty.String), Optional: true},
+				"arn":        {AttributeType: cty.String, Optional: true, Computed: true},
+			},
+			NestedBlocks: map[string]*tfjson.SchemaBlockType{
+				"timeouts": {
+					NestingMode: tfjson.SchemaNestingModeSingle,
+					MaxItems:    1,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"create": {AttributeType: cty.String, Optional: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := DescribeResource("aws_vpc", schema)
+	for _, want := range []string{
+		"aws_vpc\n",
+		"cidr_block  string         required\n",
+		"tags        map of string  optional\n",
+		"arn         string         optional, computed\n",
+		"id          string         computed\n",
+		"Block timeouts (single, at most 1):\n",
+		"create  string  optional\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in description:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package inject
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"golang.org/x/mod/semver"
+)
+
+type CLIKind string
+
+const (
+	CLITerraform CLIKind = "terraform"
+	CLITofu      CLIKind = "tofu"
+)
+
+// CLI is the Terraform-compatible binary used to initialise temp modules and
+// read provider schemas.
+type CLI struct {
+	Kind    CLIKind
+	Path    string
+	Version string
+}
+
+type cliVersionOutput struct {
+	TerraformVersion string `json:"terraform_version"`
+	TofuVersion      string `json:"tofu_version"`
+}
+
+var (
+	resolvedCLIs   = make(map[string]*CLI)
+	resolvedCLIsMu sync.Mutex
+
+	versionLineRe = regexp.MustCompile(`^(Terraform|OpenTofu) v(\S+)`)
+)
+
+// ResolveCLI returns the CLI configured in cfg.CLI, which may be "terraform",
+// "tofu" or a path to a binary. When unset, terraform is preferred and tofu is
+// used if terraform is not on the PATH.
+func ResolveCLI(cfg *config.Config) (*CLI, error) {
+	setting := strings.TrimSpace(cfg.CLI)
+
+	resolvedCLIsMu.Lock()
+	defer resolvedCLIsMu.Unlock()
+
+	if cli, ok := resolvedCLIs[setting]; ok {
+		return cli, nil
+	}
+
+	var candidates []string
+	if setting == "" {
+		candidates = []string{string(CLITerraform), string(CLITofu)}
+	} else {
+		candidates = []string{setting}
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		path, err := exec.LookPath(candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		cli, err := detectCLI(path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		log.Printf("Using %s %s (%s)", cli.Kind, cli.Version, cli.Path)
+		resolvedCLIs[setting] = cli
+		return cli, nil
+	}
+
+	if setting == "" {
+		return nil, fmt.Errorf("neither terraform nor tofu was found on PATH: %w", lastErr)
+	}
+	return nil, fmt.Errorf("configured cli %q is not usable: %w", setting, lastErr)
+}
+
+// detectCLI identifies the binary from its version output rather than its
+// name, so custom paths and wrappers are classified correctly.
+func detectCLI(path string) (*CLI, error) {
+	out, err := exec.Command(path, "version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s version: %w", path, err)
+	}
+
+	cli := &CLI{Path: path}
+	firstLine := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	if m := versionLineRe.FindStringSubmatch(firstLine); m != nil {
+		cli.Version = m[2]
+		cli.Kind = CLITerraform
+		if m[1] == "OpenTofu" {
+			cli.Kind = CLITofu
+		}
+		return cli, nil
+	}
+
+	jsonOut, err := exec.Command(path, "version", "-json").Output()
+	if err == nil {
+		var v cliVersionOutput
+		if json.Unmarshal(jsonOut, &v) == nil {
+			cli.Version = v.TerraformVersion
+			cli.Kind = CLITerraform
+			if v.TofuVersion != "" {
+				cli.Version = v.TofuVersion
+				cli.Kind = CLITofu
+			}
+			if cli.Version != "" {
+				return cli, nil
+			}
+		}
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if base == string(CLITofu) {
+		cli.Kind = CLITofu
+	} else {
+		cli.Kind = CLITerraform
+	}
+	log.Printf("could not detect version of %s; assuming %s", path, cli.Kind)
+	return cli, nil
+}
+
+func (c *CLI) InitArgs() []string {
+	return []string{"init", "-no-color", "-input=false", "-backend=false"}
+}
+
+// SchemaArgs returns the arguments for dumping provider schemas. Terraform
+// only supports JSON schema output from 0.12 onwards; every tofu release does.
+func (c *CLI) SchemaArgs() ([]string, error) {
+	if c.Kind == CLITerraform && c.Version != "" && !semverAtLeast(c.Version, "0.12.0") {
+		return nil, fmt.Errorf("terraform %s does not support `providers schema -json`; 0.12 or newer is required", c.Version)
+	}
+	return []string{"providers", "schema", "-json"}, nil
+}
+
+// Command builds a command for the CLI running in dir. TF_IN_AUTOMATION is
+// honoured by both terraform and tofu.
+func (c *CLI) Command(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, c.Path, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	return cmd
+}
+
+func semverAtLeast(version, minimum string) bool {
+	if version == "" {
+		return false
+	}
+	return semver.Compare("v"+strings.TrimPrefix(version, "v"), "v"+minimum) >= 0
+}
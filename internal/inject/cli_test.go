@@ -0,0 +1,81 @@
+package inject
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+func writeFakeCLI(t *testing.T, name, versionOutput string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake CLI scripts require a POSIX shell")
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	script := "#!/bin/sh\necho '" + versionOutput + "'\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake cli: %v", err)
+	}
+	return path
+}
+
+func TestDetectCLI(t *testing.T) {
+	tests := []struct {
+		name        string
+		binary      string
+		output      string
+		wantKind    CLIKind
+		wantVersion string
+	}{
+		{name: "terraform", binary: "terraform", output: "Terraform v1.9.5", wantKind: CLITerraform, wantVersion: "1.9.5"},
+		{name: "tofu", binary: "tofu", output: "OpenTofu v1.8.2", wantKind: CLITofu, wantVersion: "1.8.2"},
+		{name: "custom tofu wrapper", binary: "my-iac", output: "OpenTofu v1.7.0", wantKind: CLITofu, wantVersion: "1.7.0"},
+		{name: "unknown output", binary: "tofu", output: "something else", wantKind: CLITofu},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := detectCLI(writeFakeCLI(t, tt.binary, tt.output))
+			if err != nil {
+				t.Fatalf("detectCLI failed: %v", err)
+			}
+			if cli.Kind != tt.wantKind {
+				t.Errorf("Expected kind %s, got %s", tt.wantKind, cli.Kind)
+			}
+			if cli.Version != tt.wantVersion {
+				t.Errorf("Expected version %q, got %q", tt.wantVersion, cli.Version)
+			}
+		})
+	}
+}
+
+func TestResolveCLIConfiguredPath(t *testing.T) {
+	path := writeFakeCLI(t, "tofu", "OpenTofu v1.8.2")
+
+	cli, err := ResolveCLI(&config.Config{CLI: path})
+	if err != nil {
+		t.Fatalf("ResolveCLI failed: %v", err)
+	}
+	if cli.Kind != CLITofu || cli.Path != path {
+		t.Errorf("Unexpected cli: %+v", cli)
+	}
+
+	if _, err := ResolveCLI(&config.Config{CLI: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("ResolveCLI should fail for a missing binary")
+	}
+}
+
+func TestSchemaArgsVersionGate(t *testing.T) {
+	if _, err := (&CLI{Kind: CLITerraform, Version: "0.11.14"}).SchemaArgs(); err == nil {
+		t.Error("SchemaArgs should reject terraform older than 0.12")
+	}
+	if _, err := (&CLI{Kind: CLITerraform, Version: "1.9.0"}).SchemaArgs(); err != nil {
+		t.Errorf("SchemaArgs failed for terraform 1.9.0: %v", err)
+	}
+	if _, err := (&CLI{Kind: CLITofu, Version: "1.6.0"}).SchemaArgs(); err != nil {
+		t.Errorf("SchemaArgs failed for tofu: %v", err)
+	}
+}
@@ -1,12 +1,17 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/phergul/tfsnap/internal/config"
 	"github.com/phergul/tfsnap/internal/util"
 )
 
+// ErrNoExample is wrapped by clients when the resource has no example, as
+// opposed to the lookup itself failing.
+var ErrNoExample = errors.New("no example found")
+
 type ExampleResult struct {
 	Content string
 	Name    string
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/phergul/tfsnap/internal/config"
@@ -62,3 +63,14 @@ func (m *mockClient) SetSpecificResourceName(name string) {}
 func (m *mockClient) GetProviderMetadata() util.ProviderMetadata {
 	return util.ProviderMetadata{}
 }
+
+func TestGithubClientOffline(t *testing.T) {
+	workDir := t.TempDir()
+	util.ConfigureHTTPCache(workDir, true)
+	defer util.ConfigureHTTPCache(workDir, false)
+
+	c := &GithubExampleClient{config: &config.Config{WorkingDirectory: workDir}}
+	if _, err := c.GetExamples("1.0.0", "aws_vpc"); !errors.Is(err, util.ErrOffline) {
+		t.Errorf("Expected ErrOffline, got %v", err)
+	}
+}
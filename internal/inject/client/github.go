@@ -2,8 +2,10 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
@@ -74,6 +76,11 @@ func newGithubClient() *github.Client {
 }
 
 func (c *GithubExampleClient) GetExamples(providerVersion, resourceType string) ([]ExampleResult, error) {
+	// examples are read straight from the GitHub API, which isn't cached
+	if util.Offline() {
+		return nil, fmt.Errorf("%w: GitHub examples for %s are not cached; use the registry or local example client", util.ErrOffline, resourceType)
+	}
+
 	var strategy ExampleSearchStrategy
 	if c.config.WorkingStrategy == "" {
 		log.Println("(findGithubExamples) no config strategy found; using fallback strategy")
@@ -98,7 +105,11 @@ func (c *GithubExampleClient) GetExamples(providerVersion, resourceType string)
 
 	_, contents, _, err := c.client.Repositories.GetContents(context.Background(), owner, repo, "examples", opts)
 	if err != nil {
-		return nil, fmt.Errorf("no examples directory: %w", err)
+		var ghErr *github.ErrorResponse
+		if errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w for resource %s: repository has no examples directory", ErrNoExample, resourceType)
+		}
+		return nil, fmt.Errorf("failed to read examples directory: %w", err)
 	}
 
 	if strategy != StrategyNone {
@@ -131,7 +142,7 @@ func (c *GithubExampleClient) GetExamples(providerVersion, resourceType string)
 		return examples, nil
 	}
 
-	return nil, fmt.Errorf("no example found for resource %s", resourceType)
+	return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 }
 
 func (c *GithubExampleClient) tryStrategy(strategy ExampleSearchStrategy, contents []*github.RepositoryContent, owner, repo, resourceType string, opts *github.RepositoryContentGetOptions) ([]ExampleResult, error) {
@@ -167,7 +178,7 @@ func (c *GithubExampleClient) findInResourcesDir(contents []*github.RepositoryCo
 			}
 		}
 	}
-	return nil, fmt.Errorf("no example found for resource %s", resourceType)
+	return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 }
 
 func (c *GithubExampleClient) findInNamedDir(contents []*github.RepositoryContent, owner, repo, resourceType string, opts *github.RepositoryContentGetOptions) ([]ExampleResult, error) {
@@ -177,7 +188,7 @@ func (c *GithubExampleClient) findInNamedDir(contents []*github.RepositoryConten
 			return c.searchExamplesDirectory(owner, repo, content.GetPath(), resourceType, opts)
 		}
 	}
-	return nil, fmt.Errorf("no example found for resource %s", resourceType)
+	return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 }
 
 func (c *GithubExampleClient) findTFFileInRoot(contents []*github.RepositoryContent, owner, repo, resourceType string, opts *github.RepositoryContentGetOptions) ([]ExampleResult, error) {
@@ -187,7 +198,7 @@ func (c *GithubExampleClient) findTFFileInRoot(contents []*github.RepositoryCont
 			return c.fetchAndValidate(owner, repo, content.GetPath(), resourceType, opts)
 		}
 	}
-	return nil, fmt.Errorf("no example found for resource %s", resourceType)
+	return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 }
 
 func (c *GithubExampleClient) recursiveSearch(contents []*github.RepositoryContent, owner, repo, resourceType string, opts *github.RepositoryContentGetOptions) ([]ExampleResult, error) {
@@ -211,7 +222,7 @@ func (c *GithubExampleClient) recursiveSearch(contents []*github.RepositoryConte
 		for _, e := range errors {
 			log.Printf("recursiveSearch error: %v", e)
 		}
-		return nil, fmt.Errorf("no examples found for resource %s", resourceType)
+		return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 	}
 
 	return totalExamples, nil
@@ -260,7 +271,7 @@ func (c *GithubExampleClient) searchExamplesDirectory(owner, repo, dirPath, reso
 	}
 
 	if len(totalExamples) == 0 {
-		return nil, fmt.Errorf("no examples found for resource %s", resourceType)
+		return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 	}
 	return totalExamples, nil
 }
@@ -283,7 +294,7 @@ func (c *GithubExampleClient) fetchAndValidate(owner, repo, filePath, resourceTy
 	matches := re.FindAllStringIndex(text, -1)
 
 	if len(matches) == 0 {
-		return nil, fmt.Errorf("no resource examples found for %s", resourceType)
+		return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 	}
 
 	exampleBlocks, err := extractResourceBlocks(text, matches)
@@ -307,7 +318,7 @@ func (c *GithubExampleClient) fetchAndValidate(owner, repo, filePath, resourceTy
 
 	if len(results) == 0 {
 		log.Printf("No resource examples found for %s in %s", resourceType, filePath)
-		return nil, fmt.Errorf("no resource examples found for %s", resourceType)
+		return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 	}
 
 	log.Printf("Found resource example for %s in %s", resourceType, filePath)
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+const LocalClientName = "local"
+
+// LocalExampleClient reads examples from the provider repository checked out
+// at Provider.ProviderDirectory, so it works offline and sees unreleased
+// resources.
+type LocalExampleClient struct {
+	config               *config.Config
+	providerDir          string
+	providerMetadata     util.ProviderMetadata
+	specificResourceName string
+}
+
+func NewLocalExampleClient(cfg *config.Config) (ExampleClient, error) {
+	providerDir := cfg.Provider.ProviderDirectory
+	if providerDir == "" {
+		return nil, fmt.Errorf("provider directory is not configured")
+	}
+	if !util.DirExists(providerDir) {
+		return nil, fmt.Errorf("provider directory %s does not exist", providerDir)
+	}
+
+	namespace := ""
+	parts := strings.Split(cfg.Provider.SourceMapping.RegistrySource, "/")
+	if len(parts) >= 2 {
+		namespace = parts[len(parts)-2]
+	}
+
+	return &LocalExampleClient{
+		config:      cfg,
+		providerDir: providerDir,
+		providerMetadata: util.ProviderMetadata{
+			ID:        fmt.Sprintf("%s/%s", namespace, cfg.Provider.Name),
+			Namespace: namespace,
+			Name:      cfg.Provider.Name,
+			Source:    providerDir,
+		},
+	}, nil
+}
+
+func init() {
+	Register(LocalClientName, NewLocalExampleClient)
+}
+
+func (c *LocalExampleClient) SetSpecificResourceName(name string) {
+	c.specificResourceName = name
+}
+
+func (c *LocalExampleClient) GetProviderMetadata() util.ProviderMetadata {
+	return c.providerMetadata
+}
+
+func (c *LocalExampleClient) GetExamples(providerVersion, resourceType string) ([]ExampleResult, error) {
+	shortType := strings.TrimPrefix(resourceType, c.providerMetadata.Name+"_")
+	fullType := c.providerMetadata.Name + "_" + shortType
+
+	if examples, err := c.findInExamplesDir(fullType); err == nil {
+		return examples, nil
+	} else {
+		log.Printf("local examples: %v", err)
+	}
+
+	if examples, err := c.findInDocs(shortType, fullType); err == nil {
+		return examples, nil
+	} else {
+		log.Printf("local docs: %v", err)
+	}
+
+	return nil, fmt.Errorf("%w for resource %s in %s", ErrNoExample, fullType, c.providerDir)
+}
+
+// findInExamplesDir scans examples/ for .tf files declaring the resource,
+// preferring the conventional examples/resources/<type> directory.
+func (c *LocalExampleClient) findInExamplesDir(resourceType string) ([]ExampleResult, error) {
+	examplesDir := filepath.Join(c.providerDir, "examples")
+	if !util.DirExists(examplesDir) {
+		return nil, fmt.Errorf("no examples directory in %s", c.providerDir)
+	}
+
+	searchDirs := []string{
+		filepath.Join(examplesDir, "resources", resourceType),
+		examplesDir,
+	}
+
+	for _, dir := range searchDirs {
+		if !util.DirExists(dir) {
+			continue
+		}
+
+		var results []ExampleResult
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(path, ".tf") {
+				return nil
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				log.Printf("failed to read %s: %v", path, err)
+				return nil
+			}
+
+			examples, err := extractTFResources(string(data), resourceType, c.specificResourceName)
+			if err != nil {
+				return nil
+			}
+			log.Printf("Found local example for %s in %s", resourceType, path)
+			results = append(results, examples...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(results) > 0 {
+			return results, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no examples found for resource %s", resourceType)
+}
+
+// findInDocs reads the resource documentation page from the current docs
+// layout or the older website layout and extracts its HCL code blocks.
+func (c *LocalExampleClient) findInDocs(shortType, fullType string) ([]ExampleResult, error) {
+	candidates := []string{
+		filepath.Join(c.providerDir, "docs", "resources", shortType+".md"),
+		filepath.Join(c.providerDir, "docs", "resources", shortType+".html.md"),
+		filepath.Join(c.providerDir, "website", "docs", "r", shortType+".html.markdown"),
+		filepath.Join(c.providerDir, "website", "docs", "r", shortType+".markdown"),
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		examples, err := extractHCL(string(data), fullType, c.specificResourceName)
+		if err != nil {
+			log.Printf("[%s] %v", path, err)
+			continue
+		}
+		log.Printf("Found local doc example for %s in %s", fullType, path)
+		return examples, nil
+	}
+
+	return nil, fmt.Errorf("no documentation found for resource %s", fullType)
+}
+
+func extractTFResources(content, resourceType, specificName string) ([]ExampleResult, error) {
+	re := regexp.MustCompile(fmt.Sprintf(`resource\s+"%s"\s+"[^"]+"\s*{`, regexp.QuoteMeta(resourceType)))
+	matches := re.FindAllStringIndex(content, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no resource examples found for %s", resourceType)
+	}
+
+	blocks, err := extractResourceBlocks(content, matches)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ExampleResult
+	for _, block := range blocks {
+		name, err := extractResourceName(block, resourceType)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		if specificName != "" && name != specificName {
+			continue
+		}
+		results = append(results, ExampleResult{
+			Content: block,
+			Name:    name,
+		})
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no resource examples found for %s", resourceType)
+	}
+	return results, nil
+}
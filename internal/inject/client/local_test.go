@@ -0,0 +1,114 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func newTestLocalClient(t *testing.T, providerDir string) ExampleClient {
+	t.Helper()
+	cfg := &config.Config{
+		Provider: config.Provider{
+			Name:              "test",
+			ProviderDirectory: providerDir,
+			SourceMapping: config.SourceMapping{
+				RegistrySource: "registry.terraform.io/acme/test",
+			},
+		},
+	}
+
+	c, err := New(LocalClientName, cfg)
+	if err != nil {
+		t.Fatalf("New(local) failed: %v", err)
+	}
+	return c
+}
+
+func TestLocalClientExamplesDir(t *testing.T) {
+	providerDir := t.TempDir()
+	writeTestFile(t, filepath.Join(providerDir, "examples", "resources", "test_widget", "resource.tf"), `
+resource "test_widget" "first" {
+  name = "one"
+}
+
+resource "test_widget" "second" {
+  name = "two"
+  nested {
+    value = 1
+  }
+}
+`)
+
+	c := newTestLocalClient(t, providerDir)
+	if meta := c.GetProviderMetadata(); meta.Name != "test" || meta.Namespace != "acme" {
+		t.Errorf("Unexpected provider metadata: %+v", meta)
+	}
+
+	examples, err := c.GetExamples("", "widget")
+	if err != nil {
+		t.Fatalf("GetExamples failed: %v", err)
+	}
+	if len(examples) != 2 {
+		t.Fatalf("Expected 2 examples, got %d", len(examples))
+	}
+	if examples[1].Name != "second" {
+		t.Errorf("Expected second example name 'second', got %q", examples[1].Name)
+	}
+
+	c.SetSpecificResourceName("second")
+	examples, err = c.GetExamples("", "test_widget")
+	if err != nil {
+		t.Fatalf("GetExamples with specific name failed: %v", err)
+	}
+	if len(examples) != 1 || examples[0].Name != "second" {
+		t.Errorf("Expected only the 'second' example, got %+v", examples)
+	}
+}
+
+func TestLocalClientDocs(t *testing.T) {
+	providerDir := t.TempDir()
+	writeTestFile(t, filepath.Join(providerDir, "docs", "resources", "gadget.md"), "# test_gadget\n\n```terraform\nresource \"test_gadget\" \"example\" {\n  size = 2\n}\n```\n")
+	writeTestFile(t, filepath.Join(providerDir, "website", "docs", "r", "legacy.html.markdown"), "```hcl\nresource \"test_legacy\" \"old\" {\n  enabled = true\n}\n```\n")
+
+	c := newTestLocalClient(t, providerDir)
+
+	examples, err := c.GetExamples("", "gadget")
+	if err != nil {
+		t.Fatalf("GetExamples from docs failed: %v", err)
+	}
+	if len(examples) != 1 || examples[0].Name != "example" {
+		t.Errorf("Unexpected docs examples: %+v", examples)
+	}
+
+	examples, err = c.GetExamples("", "legacy")
+	if err != nil {
+		t.Fatalf("GetExamples from website docs failed: %v", err)
+	}
+	if len(examples) != 1 || examples[0].Name != "old" {
+		t.Errorf("Unexpected website docs examples: %+v", examples)
+	}
+
+	if _, err := c.GetExamples("", "missing"); err == nil {
+		t.Error("GetExamples should fail for a resource without examples")
+	}
+}
+
+func TestLocalClientMissingDirectory(t *testing.T) {
+	_, err := New(LocalClientName, &config.Config{})
+	if err == nil {
+		t.Error("New(local) should fail without a provider directory")
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 type RegistryExampleClient struct {
 	config               *config.Config
+	registry             *util.Registry
 	providerMetadata     util.ProviderMetadata
 	Docs                 Docs
 	specificResourceName string
@@ -40,14 +41,21 @@ type DocsResponse struct {
 }
 
 func NewRegistryExampleClient(cfg *config.Config) (ExampleClient, error) {
+	registry, _, err := util.RegistryForSource(cfg.Provider.SourceMapping.RegistrySource)
+	if err != nil {
+		log.Printf("failed to resolve registry: %v", err)
+		return nil, err
+	}
+
 	providerMetadata, err := util.GetProviderRegistryMeta(cfg.Provider.SourceMapping.RegistrySource)
 	if err != nil {
-		log.Fatalf("Failed to get provider metadata: %v", err)
-		return &RegistryExampleClient{}, err
+		log.Printf("failed to get provider metadata: %v", err)
+		return nil, err
 	}
 
 	return &RegistryExampleClient{
 		config:           cfg,
+		registry:         registry,
 		providerMetadata: providerMetadata,
 	}, nil
 }
@@ -58,34 +66,66 @@ func (c *RegistryExampleClient) SetSpecificResourceName(name string) {
 
 func (c *RegistryExampleClient) GetExamples(providerVersion, resourceType string) ([]ExampleResult, error) {
 	if c.Docs == nil {
-		providerId := fmt.Sprintf("%s/%s", c.providerMetadata.Namespace, c.providerMetadata.Name)
-		providerVersion = strings.TrimPrefix(providerVersion, "v")
-		docs, err := getRegistryDocs(providerId, providerVersion)
-		if err != nil {
+		if err := c.loadDocs(providerVersion); err != nil {
 			return nil, err
 		}
-
-		//remove datasources
-		docs = slices.DeleteFunc(docs, func(d Doc) bool {
-			return d.Category == "datasource"
-		})
-
-		docsMap := make(map[string]Doc, len(docs))
-		for _, doc := range docs {
-			docsMap[doc.Title] = doc
-		}
-		c.Docs = docsMap
 	}
 
 	if examples, err := c.getResourceExamples(resourceType); err != nil {
 		return nil, fmt.Errorf("failed to get examples for resource %s: %w", resourceType, err)
 	} else if len(examples) == 0 {
-		return nil, fmt.Errorf("no examples found for resource %s", resourceType)
+		return nil, fmt.Errorf("%w for resource %s", ErrNoExample, resourceType)
 	} else {
 		return examples, nil
 	}
 }
 
+func (c *RegistryExampleClient) loadDocs(providerVersion string) error {
+	providerId := fmt.Sprintf("%s/%s", c.providerMetadata.Namespace, c.providerMetadata.Name)
+	providerVersion = strings.TrimPrefix(providerVersion, "v")
+	docs, err := getRegistryDocs(c.registry, providerId, providerVersion)
+	if err != nil {
+		return err
+	}
+
+	//remove datasources
+	docs = slices.DeleteFunc(docs, func(d Doc) bool {
+		return d.Category == "datasource"
+	})
+
+	docsMap := make(map[string]Doc, len(docs))
+	for _, doc := range docs {
+		docsMap[doc.Title] = doc
+	}
+	c.Docs = docsMap
+	return nil
+}
+
+// WarmRegistryCache fetches the doc index and every resource doc of a provider
+// version so that later injections can be served offline.
+func WarmRegistryCache(cfg *config.Config, providerVersion string) (int, error) {
+	ec, err := NewRegistryExampleClient(cfg)
+	if err != nil {
+		return 0, err
+	}
+	c := ec.(*RegistryExampleClient)
+
+	if err := c.loadDocs(providerVersion); err != nil {
+		return 0, err
+	}
+
+	fetched := 0
+	for title, doc := range c.Docs {
+		docURL := fmt.Sprintf("%s/v2/provider-docs/%s", c.registry.BaseURL, doc.Id)
+		if _, err := util.GetJsonCached[RegistryExampleResponse](docURL, c.registry.TokenFor(docURL), util.DocsTTL); err != nil {
+			log.Printf("failed to warm docs for %s: %v", title, err)
+			continue
+		}
+		fetched++
+	}
+	return fetched, nil
+}
+
 func (c *RegistryExampleClient) GetProviderMetadata() util.ProviderMetadata {
 	return c.providerMetadata
 }
@@ -94,10 +134,9 @@ func init() {
 	Register("registry", NewRegistryExampleClient)
 }
 
-func getRegistryDocs(providerId, providerVersion string) ([]Doc, error) {
-	url := fmt.Sprintf("https://registry.terraform.io/v1/providers/%s/%s", providerId, providerVersion)
-
-	docs, err := util.GetJson[DocsResponse](url)
+func getRegistryDocs(registry *util.Registry, providerId, providerVersion string) ([]Doc, error) {
+	docsURL := registry.ProviderURL(providerId, providerVersion)
+	docs, err := util.GetJsonCached[DocsResponse](docsURL, registry.TokenFor(docsURL), util.DocsTTL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get registry docs: %w", err)
 	}
@@ -112,10 +151,11 @@ func (c *RegistryExampleClient) getResourceExamples(resourceType string) ([]Exam
 
 	doc, ok := c.Docs[resourceType]
 	if !ok {
-		return nil, fmt.Errorf("no documentation found for resource type: %s", resourceType)
+		return nil, fmt.Errorf("%w: no documentation for resource type %s", ErrNoExample, resourceType)
 	}
 
-	rawExampleContent, err := util.GetJson[RegistryExampleResponse](fmt.Sprintf("https://registry.terraform.io/v2/provider-docs/%s", doc.Id))
+	docURL := fmt.Sprintf("%s/v2/provider-docs/%s", c.registry.BaseURL, doc.Id)
+	rawExampleContent, err := util.GetJsonCached[RegistryExampleResponse](docURL, c.registry.TokenFor(docURL), util.DocsTTL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get example content for resource %s: %w", resourceType, err)
 	}
@@ -126,12 +166,12 @@ func (c *RegistryExampleClient) getResourceExamples(resourceType string) ([]Exam
 	if examples, err := extractHCL(rawExampleContent.Data.Attributes.Content, resourceType, c.specificResourceName); err == nil {
 		return examples, nil
 	} else {
-		return nil, fmt.Errorf("failed to extract HCL for resource %s: %w", resourceType, err)
+		return nil, fmt.Errorf("%w in the documentation of %s: %v", ErrNoExample, resourceType, err)
 	}
 }
 
 func extractHCL(content, resourceType, specificName string) ([]ExampleResult, error) {
-	codeBlockRe := regexp.MustCompile("(?s)```terraform\\s*(.*?)\\s*```")
+	codeBlockRe := regexp.MustCompile("(?s)```(?:terraform|hcl|tf)\\s*(.*?)\\s*```")
 	matches := codeBlockRe.FindAllStringSubmatch(content, -1)
 
 	if len(matches) == 0 {
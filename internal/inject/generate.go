@@ -0,0 +1,141 @@
+package inject
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+const skeletonResourceName = "test"
+
+// generateExampleFromSchema builds an example for resources that have no
+// documented example. The registry schema for the version is tried first,
+// falling back to the local provider build for resources that are not
+// released yet.
+func generateExampleFromSchema(ctx context.Context, cfg *config.Config, resourceType, version string, dependency bool) ([]string, error) {
+	fullResourceType := resourceType
+	if !strings.HasPrefix(resourceType, cfg.Provider.Name+"_") {
+		fullResourceType = cfg.Provider.Name + "_" + resourceType
+	}
+
+	providerSchema, err := findSchemaForResource(ctx, cfg, fullResourceType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := LoadSampleRules(cfg.WorkingDirectory)
+	if err != nil {
+		log.Printf("ignoring sample value rules: %v", err)
+	}
+
+	g := &exampleGenerator{
+		providerName: cfg.Provider.Name,
+		schema:       providerSchema,
+		rules:        rules,
+		dependency:   dependency,
+		visited:      make(map[string]bool),
+	}
+
+	fmt.Printf("No documented example found for %s; generating one from the provider schema\n", fullResourceType)
+	g.generate(fullResourceType)
+
+	return g.resources, nil
+}
+
+func findSchemaForResource(ctx context.Context, cfg *config.Config, resourceType, version string) (*tfjson.ProviderSchema, error) {
+	schema, err := RetrieveProviderSchema(ctx, cfg, version, false)
+	if err != nil {
+		log.Printf("failed to retrieve registry schema for generated example: %v", err)
+	} else if _, ok := ValidateResource(schema, resourceType); ok {
+		return schema, nil
+	}
+
+	if cfg.Provider.SourceMapping.LocalSource == "" {
+		return nil, fmt.Errorf("resource %s not found in provider schema and no local source is configured", resourceType)
+	}
+
+	log.Printf("resource %s not in registry schema; trying local provider", resourceType)
+	schema, err = RetrieveProviderSchema(ctx, cfg, "", true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve local provider schema: %w", err)
+	}
+	if _, ok := ValidateResource(schema, resourceType); !ok {
+		return nil, fmt.Errorf("resource %s not found in registry or local provider schema", resourceType)
+	}
+	return schema, nil
+}
+
+type exampleGenerator struct {
+	providerName string
+	schema       *tfjson.ProviderSchema
+	rules        []SampleRule
+	dependency   bool
+	visited      map[string]bool
+	resources    []string
+}
+
+// generate renders the resource after any resources it references, so the
+// returned slice is in dependency order.
+func (g *exampleGenerator) generate(resourceType string) {
+	g.visited[resourceType] = true
+	resourceSchema := g.schema.ResourceSchemas[resourceType]
+
+	references := make(map[string]string)
+	if g.dependency {
+		deps := g.inferDependencies(resourceSchema)
+		for _, attr := range util.SortedKeys(deps) {
+			depType := deps[attr]
+			if !g.visited[depType] {
+				fmt.Printf("Generating dependency %s\n", depType)
+				g.generate(depType)
+			}
+			ref := fmt.Sprintf("%s.%s.id", depType, skeletonResourceName)
+			if strings.HasSuffix(attr, "_ids") {
+				ref = "[" + ref + "]"
+			}
+			references[attr] = ref
+		}
+	}
+
+	g.resources = append(g.resources, buildSkeleton(resourceSchema, resourceType, SkeletonOptions{
+		RequiredOnly: true,
+		SampleRules:  g.rules,
+		References:   references,
+	}))
+}
+
+// inferDependencies maps required attributes named like "<resource>_id" or
+// "<resource>_ids" to the provider resource type they most likely refer to.
+func (g *exampleGenerator) inferDependencies(resourceSchema *tfjson.Schema) map[string]string {
+	deps := make(map[string]string)
+	if resourceSchema == nil || resourceSchema.Block == nil {
+		return deps
+	}
+
+	for _, key := range util.SortedKeys(resourceSchema.Block.Attributes) {
+		attr := resourceSchema.Block.Attributes[key]
+		if !attr.Required {
+			continue
+		}
+
+		base, ok := strings.CutSuffix(key, "_ids")
+		if !ok {
+			base, ok = strings.CutSuffix(key, "_id")
+		}
+		if !ok || base == "" {
+			continue
+		}
+
+		candidate := g.providerName + "_" + base
+		if _, exists := g.schema.ResourceSchemas[candidate]; exists {
+			deps[key] = candidate
+		}
+	}
+
+	return deps
+}
@@ -0,0 +1,175 @@
+package inject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/inject/client"
+	"github.com/phergul/tfsnap/internal/util"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var generatorTestSchema = &tfjson.ProviderSchema{
+	ResourceSchemas: map[string]*tfjson.Schema{
+		"test_vpc": {
+			Block: &tfjson.SchemaBlock{
+				Attributes: map[string]*tfjson.SchemaAttribute{
+					"id":         {AttributeType: cty.String, Computed: true},
+					"cidr_block": {AttributeType: cty.String, Required: true},
+				},
+			},
+		},
+		"test_subnet": {
+			Block: &tfjson.SchemaBlock{
+				Attributes: map[string]*tfjson.SchemaAttribute{
+					"id":     {AttributeType: cty.String, Computed: true},
+					"vpc_id": {AttributeType: cty.String, Required: true},
+				},
+			},
+		},
+		"test_instance": {
+			Block: &tfjson.SchemaBlock{
+				Attributes: map[string]*tfjson.SchemaAttribute{
+					"id":         {AttributeType: cty.String, Computed: true},
+					"subnet_ids": {AttributeType: cty.List(cty.String), Required: true},
+					"vpc_id":     {AttributeType: cty.String, Required: true},
+					"image_id":   {AttributeType: cty.String, Required: true},
+					"key_id":     {AttributeType: cty.String, Optional: true},
+				},
+			},
+		},
+	},
+}
+
+func TestExampleGeneratorWithDependencies(t *testing.T) {
+	g := &exampleGenerator{
+		providerName: "test",
+		schema:       generatorTestSchema,
+		dependency:   true,
+		visited:      make(map[string]bool),
+	}
+
+	g.generate("test_instance")
+
+	if len(g.resources) != 3 {
+		t.Fatalf("Expected 3 resources, got %d:\n%s", len(g.resources), strings.Join(g.resources, "\n"))
+	}
+
+	order := []string{"test_vpc", "test_subnet", "test_instance"}
+	for i, resourceType := range order {
+		if !strings.HasPrefix(g.resources[i], `resource "`+resourceType+`"`) {
+			t.Errorf("Expected resource %d to be %s, got:\n%s", i, resourceType, g.resources[i])
+		}
+	}
+
+	instance := g.resources[2]
+	if !strings.Contains(instance, "subnet_ids = [test_subnet.test.id]") {
+		t.Errorf("Expected subnet_ids to reference generated subnet:\n%s", instance)
+	}
+	if !strings.Contains(instance, "vpc_id = test_vpc.test.id") {
+		t.Errorf("Expected vpc_id to reference generated vpc:\n%s", instance)
+	}
+	if !strings.Contains(instance, `image_id = "example-id"`) {
+		t.Errorf("Expected image_id to keep its sample value:\n%s", instance)
+	}
+	if strings.Contains(instance, "key_id") {
+		t.Errorf("Optional attributes should not be generated:\n%s", instance)
+	}
+}
+
+func TestExampleGeneratorWithoutDependencies(t *testing.T) {
+	g := &exampleGenerator{
+		providerName: "test",
+		schema:       generatorTestSchema,
+		visited:      make(map[string]bool),
+	}
+
+	g.generate("test_subnet")
+
+	if len(g.resources) != 1 {
+		t.Fatalf("Expected 1 resource, got %d", len(g.resources))
+	}
+	if !strings.Contains(g.resources[0], `vpc_id = "example-id"`) {
+		t.Errorf("Expected vpc_id sample value without dependencies:\n%s", g.resources[0])
+	}
+}
+
+// stubExampleClient answers every lookup with err.
+type stubExampleClient struct {
+	err error
+}
+
+func (c *stubExampleClient) GetExamples(_, resourceType string) ([]client.ExampleResult, error) {
+	return nil, c.err
+}
+
+func (c *stubExampleClient) SetSpecificResourceName(string) {}
+
+func (c *stubExampleClient) GetProviderMetadata() util.ProviderMetadata {
+	return util.ProviderMetadata{Name: "test"}
+}
+
+func newStubInjectConfig(t *testing.T, lookupErr error) *config.Config {
+	t.Helper()
+
+	client.Register("stub", func(*config.Config) (client.ExampleClient, error) {
+		return &stubExampleClient{err: lookupErr}, nil
+	})
+	origVersions := providerVersions
+	providerVersions = func(string) ([]string, error) { return []string{"1.0.0"}, nil }
+	t.Cleanup(func() { providerVersions = origVersions })
+
+	workDir := t.TempDir()
+	cfg := &config.Config{
+		WorkingDirectory:  workDir,
+		ExampleClientType: "stub",
+		Provider: config.Provider{
+			Name:          "test",
+			SourceMapping: config.SourceMapping{RegistrySource: "acme/test"},
+		},
+	}
+	schemas := util.GetCache[tfjson.ProviderSchema](workDir, "provider_schema")
+	if err := schemas.Set("provider_schema_test_1.0.0", *generatorTestSchema); err != nil {
+		t.Fatalf("Failed to cache schema: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "main.tf"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	return cfg
+}
+
+func TestInjectResourceGeneratesWithoutExample(t *testing.T) {
+	cfg := newStubInjectConfig(t, fmt.Errorf("%w for resource test_vpc", client.ErrNoExample))
+
+	if err := InjectResource(context.Background(), cfg, "vpc", "", false); err != nil {
+		t.Fatalf("InjectResource failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.WorkingDirectory, "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read main.tf: %v", err)
+	}
+	if !strings.Contains(string(data), `resource "test_vpc"`) || !strings.Contains(string(data), "cidr_block") {
+		t.Errorf("Expected a generated test_vpc block, got:\n%s", data)
+	}
+}
+
+func TestInjectResourceReturnsLookupErrors(t *testing.T) {
+	lookupErr := errors.New("registry unavailable")
+	cfg := newStubInjectConfig(t, lookupErr)
+
+	if err := InjectResource(context.Background(), cfg, "vpc", "", false); !errors.Is(err, lookupErr) {
+		t.Fatalf("Expected the lookup error, got %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(cfg.WorkingDirectory, "main.tf"))
+	if len(data) != 0 {
+		t.Errorf("Expected nothing to be injected, got:\n%s", data)
+	}
+}
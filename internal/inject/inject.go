@@ -1,6 +1,8 @@
 package inject
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -17,6 +19,9 @@ import (
 
 const DefaultClient = "registry"
 
+// providerVersions lists the released provider versions, newest first.
+var providerVersions = util.GetAvailableProviderVersions
+
 func ValidateResource(schema *tfjson.ProviderSchema, input string) (*tfjson.Schema, bool) {
 	if schema == nil {
 		log.Println("Provider schema is nil")
@@ -34,13 +39,13 @@ func ValidateResource(schema *tfjson.ProviderSchema, input string) (*tfjson.Sche
 	return resourceSchema, ok
 }
 
-func InjectResource(cfg *config.Config, resourceType, version string, dependency bool) error {
+func InjectResource(ctx context.Context, cfg *config.Config, resourceType, version string, dependency bool) error {
 	tfPath := filepath.Join(cfg.WorkingDirectory, "main.tf")
 
-	resources, err := getResourceExampleWithDependencies(cfg, resourceType, version, dependency)
+	resources, err := getResourceExampleWithDependencies(ctx, cfg, resourceType, version, dependency)
 	if err != nil {
 		log.Println(err)
-		return fmt.Errorf("failed to inject resource. Check logs for details.")
+		return fmt.Errorf("failed to inject resource: %w", err)
 	}
 
 	for _, resource := range resources {
@@ -88,26 +93,26 @@ func writeResourceToFile(path, resource string) error {
 	return err
 }
 
-func getResourceExampleWithDependencies(cfg *config.Config, resourceType, version string, dependency bool) ([]string, error) {
-	versions, err := util.GetAvailableProviderVersions(cfg.Provider.SourceMapping.RegistrySource)
-	if err != nil {
-		log.Printf("failed to get provider versions for %s: %v", strings.Split(cfg.Provider.SourceMapping.RegistrySource, "/")[:1], err)
-		return nil, err
+func getResourceExampleWithDependencies(ctx context.Context, cfg *config.Config, resourceType, version string, dependency bool) ([]string, error) {
+	clientType := cfg.ExampleClientType
+	if clientType == "" {
+		clientType = DefaultClient
 	}
 
-	var providerVersion string
-	if version == "" {
-		providerVersion = versions[0] //latest
-	} else {
-		if !slices.Contains(versions, version) {
-			return nil, fmt.Errorf("provided version %s does not exist for provider", version)
+	providerVersion := version
+	// the local client reads the checked-out provider, so there is no released
+	// version to resolve against the registry
+	if clientType != client.LocalClientName {
+		versions, err := providerVersions(cfg.Provider.SourceMapping.RegistrySource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get provider versions for %s: %w", cfg.Provider.SourceMapping.RegistrySource, err)
 		}
-		providerVersion = version
-	}
 
-	clientType := cfg.ExampleClientType
-	if clientType == "" {
-		clientType = DefaultClient
+		if version == "" {
+			providerVersion = versions[0] //latest
+		} else if !slices.Contains(versions, version) {
+			return nil, fmt.Errorf("provided version %s does not exist for provider", version)
+		}
 	}
 
 	examplesClient, err := client.New(clientType, cfg)
@@ -116,7 +121,10 @@ func getResourceExampleWithDependencies(cfg *config.Config, resourceType, versio
 	}
 
 	examples, err := examplesClient.GetExamples(providerVersion, resourceType)
-	if err != nil {
+	if errors.Is(err, client.ErrNoExample) {
+		log.Println(err)
+		examples = nil
+	} else if err != nil {
 		return nil, fmt.Errorf("failed to get examples: %w", err)
 	}
 
@@ -144,7 +152,8 @@ func getResourceExampleWithDependencies(cfg *config.Config, resourceType, versio
 	} else if len(examples) == 1 {
 		initialResource = examples[0].Content
 	} else {
-		return nil, fmt.Errorf("no example found for resource %s", resourceType)
+		log.Printf("no example found for resource %s", resourceType)
+		return generateExampleFromSchema(ctx, cfg, resourceType, providerVersion, dependency)
 	}
 
 	if dependency {
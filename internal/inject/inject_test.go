@@ -1,9 +1,14 @@
 package inject_test
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/phergul/tfsnap/internal/config"
 	"github.com/phergul/tfsnap/internal/inject"
 	"github.com/phergul/tfsnap/internal/inject/client"
 	"github.com/phergul/tfsnap/internal/util"
@@ -90,3 +95,25 @@ func (m *mockExampleClient) SetSpecificResourceName(name string) {}
 func (m *mockExampleClient) GetProviderMetadata() util.ProviderMetadata {
 	return util.ProviderMetadata{}
 }
+
+func TestInjectResourceOfflineFails(t *testing.T) {
+	workDir := t.TempDir()
+	util.ConfigureHTTPCache(workDir, true)
+	defer util.ConfigureHTTPCache(workDir, false)
+
+	cfg := &config.Config{
+		WorkingDirectory: workDir,
+		Provider: config.Provider{
+			Name:          "aws",
+			SourceMapping: config.SourceMapping{RegistrySource: "hashicorp/aws"},
+		},
+	}
+
+	err := inject.InjectResource(context.Background(), cfg, "vpc", "", false)
+	if !errors.Is(err, util.ErrOffline) {
+		t.Fatalf("Expected ErrOffline without cached versions, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "main.tf")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be injected")
+	}
+}
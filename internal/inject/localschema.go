@@ -0,0 +1,125 @@
+package inject
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+type localSchemaEntry struct {
+	BinaryPath string                `json:"binary_path"`
+	BinaryHash string                `json:"binary_hash"`
+	Schema     tfjson.ProviderSchema `json:"schema"`
+}
+
+// localSchemaCache caches the schema of a locally built provider keyed by the
+// hash of its binary, so it is reused until the provider is rebuilt.
+type localSchemaCache struct {
+	cache        *util.FileCache[localSchemaEntry]
+	providerName string
+	binaryPath   string
+	binaryHash   string
+}
+
+func newLocalSchemaCache(cfg *config.Config) *localSchemaCache {
+	c := &localSchemaCache{
+		cache:        util.GetCache[localSchemaEntry](cfg.WorkingDirectory, "local_provider_schema"),
+		providerName: cfg.Provider.Name,
+	}
+
+	binaryPath, err := util.FindProviderBinary(cfg)
+	if err != nil {
+		log.Printf("local schema cache disabled: %v", err)
+		return c
+	}
+	hash, err := util.HashFile(binaryPath)
+	if err != nil {
+		log.Printf("local schema cache disabled: failed to hash %s: %v", binaryPath, err)
+		return c
+	}
+
+	c.binaryPath = binaryPath
+	c.binaryHash = hash
+	return c
+}
+
+func (c *localSchemaCache) enabled() bool {
+	return c.cache != nil && c.binaryHash != ""
+}
+
+// isBuildKey reports whether key is the cache key of a build of this
+// provider. The hash is matched exactly so providers whose name starts with
+// this one, such as aws_cc for aws, are left alone.
+func (c *localSchemaCache) isBuildKey(key string) bool {
+	hash, ok := strings.CutPrefix(key, c.providerName+"_")
+	if !ok || len(hash) != hex.EncodedLen(sha256.Size) {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+func (c *localSchemaCache) key() string {
+	return c.providerName + "_" + c.binaryHash
+}
+
+func (c *localSchemaCache) get() *tfjson.ProviderSchema {
+	if !c.enabled() {
+		return nil
+	}
+
+	entry, err := c.cache.Get(c.key())
+	if err != nil {
+		log.Printf("local provider schema not cached for binary %s: %v", c.binaryHash[:8], err)
+		return nil
+	}
+	if entry.BinaryHash != c.binaryHash {
+		return nil
+	}
+
+	log.Printf("Using cached schema for local provider binary %s", c.binaryHash[:8])
+	return &entry.Schema
+}
+
+func (c *localSchemaCache) set(schema *tfjson.ProviderSchema) {
+	if !c.enabled() {
+		return
+	}
+
+	entry := localSchemaEntry{
+		BinaryPath: c.binaryPath,
+		BinaryHash: c.binaryHash,
+		Schema:     *schema,
+	}
+	if err := c.cache.Set(c.key(), entry); err != nil {
+		log.Printf("failed to cache local provider schema: %v", err)
+		return
+	}
+
+	c.pruneStale()
+}
+
+// pruneStale removes schemas cached for earlier builds of the same provider.
+func (c *localSchemaCache) pruneStale() {
+	keys, err := c.cache.Keys()
+	if err != nil {
+		log.Printf("failed to list local schema cache: %v", err)
+		return
+	}
+
+	for _, key := range keys {
+		if key == c.key() || !c.isBuildKey(key) {
+			continue
+		}
+		if err := c.cache.Delete(key); err != nil {
+			log.Printf("failed to remove stale local schema %s: %v", key, err)
+			continue
+		}
+		log.Printf("Removed stale local provider schema %s", key)
+	}
+}
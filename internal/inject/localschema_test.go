@@ -0,0 +1,74 @@
+package inject
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+func TestLocalSchemaCache(t *testing.T) {
+	providerDir := t.TempDir()
+	binaryPath := filepath.Join(providerDir, "terraform-provider-test")
+	if err := os.WriteFile(binaryPath, []byte("build-1"), 0755); err != nil {
+		t.Fatalf("Failed to write provider binary: %v", err)
+	}
+
+	cfg := &config.Config{
+		WorkingDirectory: t.TempDir(),
+		Provider:         config.Provider{Name: "test", ProviderDirectory: providerDir},
+	}
+	schema := &tfjson.ProviderSchema{ResourceSchemas: map[string]*tfjson.Schema{"test_widget": {Version: 1}}}
+
+	first := newLocalSchemaCache(cfg)
+	if first.get() != nil {
+		t.Fatal("Expected empty cache")
+	}
+	first.set(schema)
+
+	cached := newLocalSchemaCache(cfg).get()
+	if cached == nil || cached.ResourceSchemas["test_widget"] == nil {
+		t.Fatalf("Expected cached schema for unchanged binary, got %+v", cached)
+	}
+
+	if err := os.WriteFile(binaryPath, []byte("build-2"), 0755); err != nil {
+		t.Fatalf("Failed to rebuild provider binary: %v", err)
+	}
+	rebuilt := newLocalSchemaCache(cfg)
+	if rebuilt.get() != nil {
+		t.Fatal("Expected cache miss after the binary changed")
+	}
+	// schemas of other providers sharing the name prefix are not builds of
+	// this one
+	other := &localSchemaCache{cache: rebuilt.cache, providerName: "test_cc", binaryHash: rebuilt.binaryHash, binaryPath: binaryPath}
+	other.set(schema)
+	rebuilt.set(schema)
+
+	keys, err := rebuilt.cache.Keys()
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	want := []string{rebuilt.key(), other.key()}
+	sort.Strings(keys)
+	sort.Strings(want)
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected only the current build and the other provider to be cached, got %v", keys)
+	}
+}
+
+func TestLocalSchemaCacheWithoutBinary(t *testing.T) {
+	cfg := &config.Config{
+		WorkingDirectory: t.TempDir(),
+		Provider:         config.Provider{Name: "test", ProviderDirectory: t.TempDir()},
+	}
+
+	c := newLocalSchemaCache(cfg)
+	c.set(&tfjson.ProviderSchema{})
+	if c.get() != nil {
+		t.Error("Expected no caching without a provider binary")
+	}
+}
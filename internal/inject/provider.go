@@ -2,11 +2,11 @@ package inject
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -15,9 +15,7 @@ import (
 	"github.com/phergul/tfsnap/internal/util"
 )
 
-var tempDir = filepath.Join(os.TempDir(), "tfsnap_tmp_module")
-
-func RetrieveProviderSchema(cfg *config.Config, version string, localProvider bool) (*tfjson.ProviderSchema, error) {
+func RetrieveProviderSchema(ctx context.Context, cfg *config.Config, version string, localProvider bool) (*tfjson.ProviderSchema, error) {
 	version = strings.TrimPrefix(version, "v")
 
 	cacheKey := fmt.Sprintf("provider_schema_%s_%s", cfg.Provider.Name, version)
@@ -30,6 +28,17 @@ func RetrieveProviderSchema(cfg *config.Config, version string, localProvider bo
 		if err != nil {
 			log.Printf("failed to retrieve provider schema from cache: %v", err)
 		}
+		if util.Offline() {
+			return nil, fmt.Errorf("%w: provider schema for %s@%s is not cached; run `tfsnap cache warm %s` while online", util.ErrOffline, cfg.Provider.Name, version, version)
+		}
+	}
+
+	var localCache *localSchemaCache
+	if localProvider {
+		localCache = newLocalSchemaCache(cfg)
+		if schema := localCache.get(); schema != nil {
+			return schema, nil
+		}
 	}
 
 	registrySource := cfg.Provider.SourceMapping.RegistrySource
@@ -37,27 +46,31 @@ func RetrieveProviderSchema(cfg *config.Config, version string, localProvider bo
 		registrySource = cfg.Provider.SourceMapping.LocalSource
 	}
 
-	if err := os.RemoveAll(tempDir); err != nil {
-        log.Printf("warning: failed to clean temp dir: %v", err)
-    }
-    if err := os.MkdirAll(tempDir, 0o755); err != nil {
-        return nil, fmt.Errorf("failed to create temp dir: %w", err)
-    }
+	tempDir, cleanup, err := newTempModuleDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
 
-	err := createTempModule(cfg.Provider.Name, registrySource, tempDir, version)
+	err = createTempModule(cfg.Provider.Name, registrySource, tempDir, version)
 	if err != nil {
 		return nil, fmt.Errorf("Injection failed: error creating temp module: %v\n", err)
 	}
 
+	cli, err := ResolveCLI(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Println("Initialising temp module...")
-	errs := terraformInit(tempDir)
+	errs := terraformInit(ctx, cli, tempDir, pluginCacheDir(cfg))
 	if errs != nil {
 		log.Println(errs[1])
 		return nil, errs[0]
 	}
 
 	log.Println("Loading provider schemas...")
-	schemas, err := loadProviderSchemas(tempDir)
+	schemas, err := loadProviderSchemas(ctx, cli, tempDir)
 	if err != nil {
 		fmt.Println("Injection failed: error loading provider schemas")
 		log.Println(err)
@@ -81,7 +94,9 @@ func RetrieveProviderSchema(cfg *config.Config, version string, localProvider bo
 	// 	return nil, fmt.Errorf("provider schema not found")
 	// }
 
-	if !localProvider {
+	if localProvider {
+		localCache.set(providerSchema)
+	} else {
 		if err := cache.Set(cacheKey, *providerSchema); err != nil {
 			log.Printf("failed to cache provider schema: %v", err)
 		}
@@ -90,6 +105,38 @@ func RetrieveProviderSchema(cfg *config.Config, version string, localProvider bo
 	return providerSchema, nil
 }
 
+// newTempModuleDir creates a temp module directory unique to this invocation.
+// It is removed by the returned cleanup func or as soon as ctx is cancelled.
+func newTempModuleDir(ctx context.Context) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "tfsnap_module_")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	remove := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("warning: failed to remove temp dir %s: %v", dir, err)
+		}
+	}
+	stop := context.AfterFunc(ctx, remove)
+
+	return dir, func() {
+		stop()
+		remove()
+	}, nil
+}
+
+// pluginCacheDir is shared by every temp module so providers are downloaded
+// once per workspace rather than on every init.
+func pluginCacheDir(cfg *config.Config) string {
+	dir := filepath.Join(cfg.WorkingDirectory, ".tfsnap", "plugin-cache")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("failed to create plugin cache dir: %v", err)
+		return ""
+	}
+	return dir
+}
+
 func createTempModule(name, source, dir, version string) error {
 	var temp string
 	if version != "" {
@@ -114,34 +161,44 @@ terraform {
 }
 `, name, source)
 	}
-	log.Printf("writing temp module to '%s'", tempDir)
+	log.Printf("writing temp module to '%s'", dir)
 	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(temp), 0644); err != nil {
 		return fmt.Errorf("failed to write temp module: %v", err)
 	}
 	return nil
 }
 
-func terraformInit(dir string) []error {
-	cmd := exec.Command("terraform", "init", "-no-color", "-input=false", "-backend=false")
-	cmd.Dir = dir
+func terraformInit(ctx context.Context, cli *CLI, dir, pluginCache string) []error {
+	cmd := cli.Command(ctx, dir, cli.InitArgs()...)
+	if pluginCache != "" {
+		// the temp module never has a lock file, so the CLI has to be allowed
+		// to link cached providers without recorded checksums
+		cmd.Env = append(cmd.Env,
+			"TF_PLUGIN_CACHE_DIR="+pluginCache,
+			"TF_PLUGIN_CACHE_MAY_BREAK_DEPENDENCY_LOCK_FILE=true",
+		)
+	}
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return []error{fmt.Errorf("terraform init failed; check logs for details"), fmt.Errorf("error on init in temp module: %s", string(out))}
+		return []error{fmt.Errorf("%s init failed; check logs for details", cli.Kind), fmt.Errorf("error on init in temp module: %s", string(out))}
 	}
 	return nil
 }
 
-func loadProviderSchemas(dir string) (*tfjson.ProviderSchemas, error) {
-	cmd := exec.Command("terraform", "providers", "schema", "-json")
-	cmd.Dir = dir
+func loadProviderSchemas(ctx context.Context, cli *CLI, dir string) (*tfjson.ProviderSchemas, error) {
+	args, err := cli.SchemaArgs()
+	if err != nil {
+		return nil, err
+	}
+	cmd := cli.Command(ctx, dir, args...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	err = cmd.Run()
 	if err != nil {
-		return nil, fmt.Errorf("terraform error: %v, stderr: %s", err, stderr.String())
+		return nil, fmt.Errorf("%s error: %v, stderr: %s", cli.Kind, err, stderr.String())
 	}
 
 	var schemas tfjson.ProviderSchemas
@@ -152,90 +209,99 @@ func loadProviderSchemas(dir string) (*tfjson.ProviderSchemas, error) {
 }
 
 func resolveProviderSchemaKey(schemas *tfjson.ProviderSchemas, cfg *config.Config) (*tfjson.ProviderSchema, string, error) {
-    if schemas == nil || schemas.Schemas == nil || len(schemas.Schemas) == 0 {
-        return nil, "", fmt.Errorf("no provider schemas returned by terraform")
-    }
+	if schemas == nil || schemas.Schemas == nil || len(schemas.Schemas) == 0 {
+		return nil, "", fmt.Errorf("no provider schemas returned by terraform")
+	}
 
-    regSrc := strings.ToLower(cfg.Provider.SourceMapping.RegistrySource)
-    locSrc := strings.ToLower(cfg.Provider.SourceMapping.LocalSource)
+	regSrc := strings.ToLower(cfg.Provider.SourceMapping.RegistrySource)
+	locSrc := strings.ToLower(cfg.Provider.SourceMapping.LocalSource)
 
-    candidates := []string{}
-    if regSrc != "" {
-        candidates = append(candidates,
-            "registry.terraform.io/"+regSrc,
-            regSrc,
-        )
-    }
-    if locSrc != "" {
-        candidates = append(candidates,
-            "registry.terraform.io/"+locSrc,
-            locSrc,
-        )
-    }
+	candidates := []string{}
+	if regSrc != "" {
+		candidates = append(candidates, normalizeSchemaKey(regSrc))
+	}
+	if locSrc != "" {
+		candidates = append(candidates, normalizeSchemaKey(locSrc))
+	}
 
-    for _, k := range candidates {
-        if ps, ok := schemas.Schemas[k]; ok && ps != nil {
-            return ps, k, nil
-        }
-    }
+	for _, c := range candidates {
+		for k, ps := range schemas.Schemas {
+			if ps != nil && normalizeSchemaKey(k) == c {
+				return ps, k, nil
+			}
+		}
+	}
 
-    if len(schemas.Schemas) == 1 {
-        for k, ps := range schemas.Schemas {
-            if ps != nil {
-                return ps, k, nil
-            }
-        }
-    }
+	if len(schemas.Schemas) == 1 {
+		for k, ps := range schemas.Schemas {
+			if ps != nil {
+				return ps, k, nil
+			}
+		}
+	}
 
-    nsNameSuffix := ""
-    if regSrc != "" {
-        parts := strings.Split(regSrc, "/")
-        if len(parts) >= 2 {
-            nsNameSuffix = "/" + parts[len(parts)-2] + "/" + parts[len(parts)-1]
-        }
-    } else if locSrc != "" {
-        parts := strings.Split(locSrc, "/")
-        if len(parts) >= 2 {
-            nsNameSuffix = "/" + parts[len(parts)-2] + "/" + parts[len(parts)-1]
-        }
-    }
-    if nsNameSuffix != "" {
-        for k, ps := range schemas.Schemas {
-            if strings.HasSuffix(strings.ToLower(k), nsNameSuffix) && ps != nil {
-                return ps, k, nil
-            }
-        }
-    }
+	nsNameSuffix := ""
+	if regSrc != "" {
+		parts := strings.Split(regSrc, "/")
+		if len(parts) >= 2 {
+			nsNameSuffix = "/" + parts[len(parts)-2] + "/" + parts[len(parts)-1]
+		}
+	} else if locSrc != "" {
+		parts := strings.Split(locSrc, "/")
+		if len(parts) >= 2 {
+			nsNameSuffix = "/" + parts[len(parts)-2] + "/" + parts[len(parts)-1]
+		}
+	}
+	if nsNameSuffix != "" {
+		for k, ps := range schemas.Schemas {
+			if strings.HasSuffix(strings.ToLower(k), nsNameSuffix) && ps != nil {
+				return ps, k, nil
+			}
+		}
+	}
 
-    providerName := strings.ToLower(cfg.Provider.Name)
-    if providerName != "" {
-        var matchKey string
-        var matchVal *tfjson.ProviderSchema
-        for k, ps := range schemas.Schemas {
-            if ps == nil {
-                continue
-            }
-            if strings.HasSuffix(strings.ToLower(k), "/"+providerName) {
-                if matchKey != "" {
-                    matchKey = ""
-                    break
-                }
-                matchKey = k
-                matchVal = ps
-            }
-        }
-        if matchKey != "" && matchVal != nil {
-            return matchVal, matchKey, nil
-        }
-    }
+	providerName := strings.ToLower(cfg.Provider.Name)
+	if providerName != "" {
+		var matchKey string
+		var matchVal *tfjson.ProviderSchema
+		for k, ps := range schemas.Schemas {
+			if ps == nil {
+				continue
+			}
+			if strings.HasSuffix(strings.ToLower(k), "/"+providerName) {
+				if matchKey != "" {
+					matchKey = ""
+					break
+				}
+				matchKey = k
+				matchVal = ps
+			}
+		}
+		if matchKey != "" && matchVal != nil {
+			return matchVal, matchKey, nil
+		}
+	}
 
-    keys := make([]string, 0, len(schemas.Schemas))
-    for k := range schemas.Schemas {
-        keys = append(keys, k)
-    }
-    return nil, "", fmt.Errorf("provider schema not found. tried: %q; available: %v", candidates, keys)
+	keys := make([]string, 0, len(schemas.Schemas))
+	for k := range schemas.Schemas {
+		keys = append(keys, k)
+	}
+	return nil, "", fmt.Errorf("provider schema not found. tried: %q; available: %v", candidates, keys)
 }
 
-func CleanupTempDir() error {
-	return os.RemoveAll(tempDir)
+// publicRegistryHosts are the default registries of the supported CLIs. The
+// same provider is reported under either host depending on the CLI in use.
+var publicRegistryHosts = []string{
+	"registry.terraform.io/",
+	"registry.opentofu.org/",
+}
+
+func normalizeSchemaKey(key string) string {
+	key = strings.ToLower(key)
+	for _, host := range publicRegistryHosts {
+		if after, ok := strings.CutPrefix(key, host); ok {
+			return after
+		}
+	}
+	return key
 }
@@ -0,0 +1,107 @@
+package inject
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+func TestResolveProviderSchemaKey(t *testing.T) {
+	aws := &tfjson.ProviderSchema{}
+	random := &tfjson.ProviderSchema{}
+
+	tests := []struct {
+		name    string
+		keys    map[string]*tfjson.ProviderSchema
+		source  string
+		local   string
+		want    *tfjson.ProviderSchema
+		wantKey string
+	}{
+		{
+			name:    "terraform registry key",
+			keys:    map[string]*tfjson.ProviderSchema{"registry.terraform.io/hashicorp/aws": aws, "registry.terraform.io/hashicorp/random": random},
+			source:  "hashicorp/aws",
+			want:    aws,
+			wantKey: "registry.terraform.io/hashicorp/aws",
+		},
+		{
+			name:    "opentofu registry key with terraform source",
+			keys:    map[string]*tfjson.ProviderSchema{"registry.opentofu.org/hashicorp/aws": aws, "registry.opentofu.org/hashicorp/random": random},
+			source:  "registry.terraform.io/hashicorp/aws",
+			want:    aws,
+			wantKey: "registry.opentofu.org/hashicorp/aws",
+		},
+		{
+			name:    "opentofu source",
+			keys:    map[string]*tfjson.ProviderSchema{"registry.opentofu.org/hashicorp/aws": aws, "registry.opentofu.org/hashicorp/random": random},
+			source:  "registry.opentofu.org/hashicorp/aws",
+			want:    aws,
+			wantKey: "registry.opentofu.org/hashicorp/aws",
+		},
+		{
+			name:    "local source",
+			keys:    map[string]*tfjson.ProviderSchema{"registry.terraform.io/local/aws": aws, "registry.terraform.io/hashicorp/random": random},
+			source:  "hashicorp/aws",
+			local:   "local/aws",
+			want:    aws,
+			wantKey: "registry.terraform.io/local/aws",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Provider: config.Provider{
+					Name: "aws",
+					SourceMapping: config.SourceMapping{
+						RegistrySource: tt.source,
+						LocalSource:    tt.local,
+					},
+				},
+			}
+			got, key, err := resolveProviderSchemaKey(&tfjson.ProviderSchemas{Schemas: tt.keys}, cfg)
+			if err != nil {
+				t.Fatalf("resolveProviderSchemaKey failed: %v", err)
+			}
+			if got != tt.want || key != tt.wantKey {
+				t.Errorf("resolveProviderSchemaKey() = %s, want %s", key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestNewTempModuleDir(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, cleanupFirst, err := newTempModuleDir(ctx)
+	if err != nil {
+		t.Fatalf("newTempModuleDir failed: %v", err)
+	}
+	second, cleanupSecond, err := newTempModuleDir(context.Background())
+	if err != nil {
+		t.Fatalf("newTempModuleDir failed: %v", err)
+	}
+	if first == second {
+		t.Fatalf("Expected unique temp dirs, got %s twice", first)
+	}
+
+	cleanupSecond()
+	if util.DirExists(second) {
+		t.Errorf("Expected %s to be removed by cleanup", second)
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for util.DirExists(first) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if util.DirExists(first) {
+		t.Errorf("Expected %s to be removed when the context is cancelled", first)
+	}
+	cleanupFirst()
+}
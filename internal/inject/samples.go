@@ -0,0 +1,184 @@
+package inject
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	"go.yaml.in/yaml/v3"
+)
+
+const sampleRulesFile = "sample_values.yaml"
+
+// SampleRule maps attributes to a sample value. Attribute and Resource are
+// glob patterns; Type optionally restricts the rule to a friendly type name
+// such as "string" or "list of string". Value is written verbatim as HCL.
+type SampleRule struct {
+	Attribute string `yaml:"attribute"`
+	Resource  string `yaml:"resource,omitempty"`
+	Type      string `yaml:"type,omitempty"`
+	Value     string `yaml:"value"`
+}
+
+type sampleRulesFileContent struct {
+	Rules []SampleRule `yaml:"rules"`
+}
+
+// builtinSampleRules are provider-neutral apart from the provider-scoped
+// rules at the top, which only apply to that provider's resources.
+var builtinSampleRules = []SampleRule{
+	{Attribute: "*_arn", Resource: "aws_*", Type: "string", Value: `"arn:aws:iam::123456789012:role/example"`},
+	{Attribute: "arn", Resource: "aws_*", Type: "string", Value: `"arn:aws:iam::123456789012:role/example"`},
+	{Attribute: "*_arns", Resource: "aws_*", Type: "list of string", Value: `["arn:aws:iam::123456789012:role/example"]`},
+	{Attribute: "*_arns", Resource: "aws_*", Type: "set of string", Value: `["arn:aws:iam::123456789012:role/example"]`},
+	{Attribute: "region", Resource: "aws_*", Type: "string", Value: `"us-east-1"`},
+	{Attribute: "availability_zone", Resource: "aws_*", Type: "string", Value: `"us-east-1a"`},
+	{Attribute: "location", Resource: "azurerm_*", Type: "string", Value: `"westeurope"`},
+	{Attribute: "*_id", Type: "string", Value: `"example-id"`},
+	{Attribute: "*_ids", Type: "list of string", Value: `["example-id"]`},
+	{Attribute: "*_ids", Type: "set of string", Value: `["example-id"]`},
+	{Attribute: "tags", Type: "map of string", Value: `{ Name = "tfsnap-example" }`},
+	{Attribute: "labels", Type: "map of string", Value: `{ name = "tfsnap-example" }`},
+	{Attribute: "name", Type: "string", Value: `"tfsnap-example"`},
+	{Attribute: "*_name", Type: "string", Value: `"tfsnap-example"`},
+	{Attribute: "name_prefix", Type: "string", Value: `"tfsnap-"`},
+	{Attribute: "description", Type: "string", Value: `"Managed by tfsnap"`},
+	{Attribute: "*cidr_block", Type: "string", Value: `"10.0.0.0/16"`},
+	{Attribute: "*cidr_blocks", Type: "list of string", Value: `["10.0.0.0/16"]`},
+	{Attribute: "*_cidr", Type: "string", Value: `"10.0.0.0/16"`},
+	{Attribute: "*_url", Type: "string", Value: `"https://example.com"`},
+	{Attribute: "url", Type: "string", Value: `"https://example.com"`},
+	{Attribute: "*_uri", Type: "string", Value: `"https://example.com"`},
+	{Attribute: "endpoint", Type: "string", Value: `"https://example.com"`},
+	{Attribute: "email", Type: "string", Value: `"user@example.com"`},
+	{Attribute: "*_email", Type: "string", Value: `"user@example.com"`},
+	{Attribute: "policy", Type: "string", Value: `jsonencode({})`},
+	{Attribute: "*_policy", Type: "string", Value: `jsonencode({})`},
+	{Attribute: "*_json", Type: "string", Value: `jsonencode({})`},
+	{Attribute: "port", Type: "number", Value: "443"},
+	{Attribute: "*_port", Type: "number", Value: "443"},
+	{Attribute: "*_count", Type: "number", Value: "1"},
+	{Attribute: "*_size", Type: "number", Value: "1"},
+	{Attribute: "*_days", Type: "number", Value: "7"},
+	{Attribute: "*timeout*", Type: "number", Value: "30"},
+}
+
+var (
+	enumRe    = regexp.MustCompile("(?i)(?:(?:valid|possible|allowed|accepted|supported|permitted) values (?:are|include|is)|one of)[:\\s]*`([^`]+)`")
+	defaultRe = regexp.MustCompile("(?i)defaults? to `([^`]+)`")
+)
+
+type sampleValues struct {
+	resourceType string
+	userRules    []SampleRule
+	references   map[string]string
+}
+
+func newSampleValues(resourceType string, userRules []SampleRule, references map[string]string) *sampleValues {
+	return &sampleValues{
+		resourceType: resourceType,
+		userRules:    userRules,
+		references:   references,
+	}
+}
+
+// LoadSampleRules reads user-defined sample value rules from the .tfsnap
+// directory. A missing file is not an error.
+func LoadSampleRules(workingDir string) ([]SampleRule, error) {
+	data, err := os.ReadFile(filepath.Join(workingDir, ".tfsnap", sampleRulesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read sample rules: %w", err)
+	}
+
+	var content sampleRulesFileContent
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse sample rules: %w", err)
+	}
+
+	return content.Rules, nil
+}
+
+// lookup picks a sample value for an attribute, preferring references to
+// generated dependencies, then explicit rules, then values documented in the
+// description, then built-in name heuristics.
+func (s *sampleValues) lookup(key, description string, ty cty.Type) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	if ref, ok := s.references[key]; ok && key != "" {
+		return ref, true
+	}
+
+	if value, ok := matchSampleRule(s.userRules, s.resourceType, key, ty); ok {
+		return value, true
+	}
+
+	if value, ok := valueFromDescription(description, ty); ok {
+		return value, true
+	}
+
+	return matchSampleRule(builtinSampleRules, s.resourceType, key, ty)
+}
+
+func matchSampleRule(rules []SampleRule, resourceType, key string, ty cty.Type) (string, bool) {
+	for _, rule := range rules {
+		if rule.Resource != "" {
+			if ok, _ := path.Match(rule.Resource, resourceType); !ok {
+				continue
+			}
+		}
+		if rule.Type != "" && rule.Type != ty.FriendlyName() {
+			continue
+		}
+		if ok, _ := path.Match(rule.Attribute, key); ok {
+			return rule.Value, true
+		}
+	}
+	return "", false
+}
+
+// valueFromDescription extracts the first enumerated value ("Valid values are
+// `a`, `b`") or the documented default from an attribute description.
+func valueFromDescription(description string, ty cty.Type) (string, bool) {
+	if description == "" || !ty.IsPrimitiveType() || ty == cty.Bool {
+		return "", false
+	}
+
+	if m := enumRe.FindStringSubmatch(description); m != nil {
+		if value, ok := formatSample(m[1], ty); ok {
+			return value, true
+		}
+	}
+
+	if m := defaultRe.FindStringSubmatch(description); m != nil {
+		return formatSample(m[1], ty)
+	}
+
+	return "", false
+}
+
+func formatSample(raw string, ty cty.Type) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	switch ty {
+	case cty.Number:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return "", false
+		}
+		return raw, true
+	case cty.String:
+		// descriptions can contain template sequences such as ${var}, which
+		// must be escaped to stay literal in the generated HCL
+		return string(hclwrite.TokensForValue(cty.StringVal(strings.Trim(raw, `"`))).Bytes()), true
+	}
+	return "", false
+}
@@ -9,39 +9,87 @@ import (
 	tfjson "github.com/hashicorp/terraform-json"
 	"github.com/phergul/tfsnap/internal/config"
 	"github.com/phergul/tfsnap/internal/util"
+	"github.com/zclconf/go-cty/cty"
 )
 
-func InjectSkeleton(cfg *config.Config, schema *tfjson.Schema, resourceType string) error {
+type SkeletonOptions struct {
+	RequiredOnly bool
+	WithDocs     bool
+	All          bool
+	SampleRules  []SampleRule
+	References   map[string]string
+}
+
+type skeletonRenderer struct {
+	opts    SkeletonOptions
+	samples *sampleValues
+}
+
+func InjectSkeleton(cfg *config.Config, schema *tfjson.Schema, resourceType string, opts SkeletonOptions) error {
 	tfPath := filepath.Join(cfg.WorkingDirectory, "main.tf")
 
-	resource := buildSkeleton(schema, resourceType)
+	rules, err := LoadSampleRules(cfg.WorkingDirectory)
+	if err != nil {
+		log.Printf("ignoring sample value rules: %v", err)
+	}
+	opts.SampleRules = append(opts.SampleRules, rules...)
+
+	resource := buildSkeleton(schema, resourceType, opts)
 
 	return writeResourceToFile(tfPath, resource)
 }
 
-func buildSkeleton(schema *tfjson.Schema, resourceType string) string {
+func buildSkeleton(schema *tfjson.Schema, resourceType string, opts SkeletonOptions) string {
 	var resource strings.Builder
+	r := skeletonRenderer{
+		opts:    opts,
+		samples: newSampleValues(resourceType, opts.SampleRules, opts.References),
+	}
 
-	resource.WriteString(fmt.Sprintf("resource \"%s\" \"test\" {\n", resourceType))
-	resource.WriteString(renderBlock(schema.Block, 1))
+	resource.WriteString(fmt.Sprintf("resource \"%s\" \"%s\" {\n", resourceType, skeletonResourceName))
+	resource.WriteString(r.renderBlock(schema.Block, 1))
 	resource.WriteString("}\n")
 
 	return resource.String()
 }
 
-func renderBlock(block *tfjson.SchemaBlock, indent int) string {
+func (r skeletonRenderer) includeAttribute(attr *tfjson.SchemaAttribute) bool {
+	if attr.Deprecated {
+		return false
+	}
+	if r.opts.RequiredOnly {
+		return attr.Required
+	}
+	return attr.Required || attr.Optional
+}
+
+func (r skeletonRenderer) renderBlock(block *tfjson.SchemaBlock, indent int) string {
 	var out strings.Builder
 	indentStr := strings.Repeat("\t", indent)
 
+	if block == nil {
+		return ""
+	}
+
 	attrKeys := util.SortedKeys(block.Attributes)
 
 	for _, key := range attrKeys {
 		attr := block.Attributes[key]
-		if attr.Deprecated || key == "id" {
+		if key == "id" {
 			continue
 		}
-		if attr.Required || attr.Optional {
-			out.WriteString(getEmptyValue(key, *attr, indent))
+		if r.includeAttribute(attr) {
+			out.WriteString(r.renderDocs(attr, indent))
+			out.WriteString(r.renderAttribute(key, attr, indent))
+		}
+	}
+
+	if r.opts.All {
+		for _, key := range attrKeys {
+			attr := block.Attributes[key]
+			if attr.Computed && !attr.Optional && !attr.Required {
+				out.WriteString(r.renderComputed(key, attr, indent))
+			}
 		}
 	}
 
@@ -49,53 +97,225 @@ func renderBlock(block *tfjson.SchemaBlock, indent int) string {
 
 	for _, name := range nestedKeys {
 		nested := block.NestedBlocks[name]
-		out.WriteString(fmt.Sprintf("%s%s {\n", indentStr, name))
-		out.WriteString(renderBlock(nested.Block, indent+1))
-		out.WriteString(fmt.Sprintf("%s}\n", indentStr))
+		for range r.blockCount(nested) {
+			out.WriteString(fmt.Sprintf("%s%s {\n", indentStr, name))
+			out.WriteString(r.renderBlock(nested.Block, indent+1))
+			out.WriteString(fmt.Sprintf("%s}\n", indentStr))
+		}
 	}
 
 	return out.String()
 }
 
-func getEmptyValue(key string, v tfjson.SchemaAttribute, indent int) string {
-	log.Printf("%s::%s [R: %t] [O: %t] [C: %t]", key, v.AttributeType.FriendlyName(), v.Required, v.Optional, v.Computed)
+// blockCount returns how many instances of a nested block to render. Blocks
+// with a MinItems constraint are repeated to satisfy it, while optional blocks
+// are rendered once unless only required content was requested.
+func (r skeletonRenderer) blockCount(nested *tfjson.SchemaBlockType) int {
+	count := int(nested.MinItems)
+	if count == 0 && !r.opts.RequiredOnly {
+		count = 1
+	}
+	if nested.MaxItems > 0 && count > int(nested.MaxItems) {
+		count = int(nested.MaxItems)
+	}
+	return count
+}
+
+func (r skeletonRenderer) renderAttribute(key string, attr *tfjson.SchemaAttribute, indent int) string {
 	indentStr := strings.Repeat("\t", indent)
 
-	switch v.AttributeType.FriendlyName() {
-	case "string":
-		log.Printf("%s, with indent %d", key, indent)
-		return fmt.Sprintf("%s%s = \"\"\n", indentStr, key)
-	case "bool":
-		log.Printf("%s, with indent %d", key, indent)
-		return fmt.Sprintf("%s%s = false\n", indentStr, key)
-	case "number":
-		log.Printf("%s, with indent %d", key, indent)
-		return fmt.Sprintf("%s%s = 0\n", indentStr, key)
-	case "list of string", "set of string":
-		log.Printf("%s, with indent %d", key, indent)
-		return fmt.Sprintf("%s%s = [ ]\n", indentStr, key)
-	case "object", "list of object", "set of object":
-		log.Printf("%s, with indent %d", key, indent)
-		var out strings.Builder
-		out.WriteString(fmt.Sprintf("%s%s {\n", indentStr, key))
-
-		elemType := v.AttributeType.ElementType()
-		nestedKeys := util.SortedKeys(elemType.AttributeTypes())
-
-		for _, nestedKey := range nestedKeys {
-			nestedType := elemType.AttributeTypes()[nestedKey]
-			nestedAttr := &tfjson.SchemaAttribute{
-				AttributeType: nestedType,
-				Required:      v.Required,
-				Optional:      v.Optional,
-			}
-			out.WriteString(getEmptyValue(nestedKey, *nestedAttr, indent+1))
+	if attr.AttributeNestedType != nil {
+		return fmt.Sprintf("%s%s = %s\n", indentStr, key, r.renderNestedType(attr.AttributeNestedType, indent))
+	}
+
+	if attr.AttributeType == cty.NilType {
+		log.Printf("attribute %s has neither a type nor a nested type; skipping", key)
+		return ""
+	}
+
+	return fmt.Sprintf("%s%s = %s\n", indentStr, key, r.renderValue(key, attr.Description, attr.AttributeType, indent))
+}
+
+func (r skeletonRenderer) renderComputed(key string, attr *tfjson.SchemaAttribute, indent int) string {
+	indentStr := strings.Repeat("\t", indent)
+	return fmt.Sprintf("%s# %s (computed, %s)\n", indentStr, key, attributeTypeName(attr))
+}
+
+// renderDocs renders the attribute description and flags as comments above the
+// attribute when docs were requested.
+func (r skeletonRenderer) renderDocs(attr *tfjson.SchemaAttribute, indent int) string {
+	if !r.opts.WithDocs {
+		return ""
+	}
+
+	var out strings.Builder
+	indentStr := strings.Repeat("\t", indent)
+
+	for _, line := range strings.Split(strings.TrimSpace(attr.Description), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("%s# %s\n", indentStr, line))
+	}
+
+	flags := []string{attributeTypeName(attr)}
+	if attr.Required {
+		flags = append(flags, "required")
+	} else {
+		flags = append(flags, "optional")
+	}
+	if attr.Computed {
+		flags = append(flags, "computed")
+	}
+	if attr.Sensitive {
+		flags = append(flags, "sensitive")
+	}
+	if attr.WriteOnly {
+		flags = append(flags, "write-only")
+	}
+	if isForceNew(attr.Description) {
+		flags = append(flags, "forces new resource")
+	}
+	out.WriteString(fmt.Sprintf("%s# [%s]\n", indentStr, strings.Join(flags, ", ")))
+
+	return out.String()
+}
+
+// isForceNew reports whether the description says that changing the attribute
+// replaces the resource. Provider schemas don't expose ForceNew directly, so
+// the conventional wording used by the plugin SDKs is the only signal.
+func isForceNew(description string) bool {
+	lower := strings.ToLower(description)
+	return strings.Contains(lower, "forces a new resource") ||
+		strings.Contains(lower, "force a new resource") ||
+		strings.Contains(lower, "forces new resource") ||
+		strings.Contains(lower, "requires replacement")
+}
+
+func attributeTypeName(attr *tfjson.SchemaAttribute) string {
+	if attr.AttributeNestedType != nil {
+		mode := attr.AttributeNestedType.NestingMode
+		if mode == "" || mode == tfjson.SchemaNestingModeSingle {
+			return "object"
 		}
+		return fmt.Sprintf("%s of object", mode)
+	}
+	if attr.AttributeType == cty.NilType {
+		return "unknown"
+	}
+	return attr.AttributeType.FriendlyName()
+}
 
-		out.WriteString(fmt.Sprintf("%s}\n", indentStr))
-		return out.String()
+// renderNestedType renders a nested attribute type as an expression, wrapping
+// the object according to its nesting mode.
+func (r skeletonRenderer) renderNestedType(nested *tfjson.SchemaNestedAttributeType, indent int) string {
+	switch nested.NestingMode {
+	case tfjson.SchemaNestingModeList, tfjson.SchemaNestingModeSet:
+		return renderCollection(indent, func(inner int) string {
+			return r.renderNestedObject(nested.Attributes, inner)
+		})
+	case tfjson.SchemaNestingModeMap:
+		return renderMapEntry(indent, func(inner int) string {
+			return r.renderNestedObject(nested.Attributes, inner)
+		})
 	default:
-		log.Printf("UNKNOWN TYPE?: %s", v.AttributeType.FriendlyName())
-		return ""
+		return r.renderNestedObject(nested.Attributes, indent)
 	}
 }
+
+func (r skeletonRenderer) renderNestedObject(attributes map[string]*tfjson.SchemaAttribute, indent int) string {
+	var out strings.Builder
+	indentStr := strings.Repeat("\t", indent)
+
+	out.WriteString("{\n")
+	for _, key := range util.SortedKeys(attributes) {
+		attr := attributes[key]
+		if !r.includeAttribute(attr) {
+			continue
+		}
+		out.WriteString(r.renderDocs(attr, indent+1))
+		out.WriteString(r.renderAttribute(key, attr, indent+1))
+	}
+	out.WriteString(indentStr + "}")
+
+	return out.String()
+}
+
+// renderValue returns a placeholder expression for the given type, using a
+// sample value when one is known for the attribute. Otherwise primitives get
+// a non-empty default, collections of primitives are left empty, and
+// collections of objects get a single element so the expected shape is
+// visible.
+func (r skeletonRenderer) renderValue(key, description string, ty cty.Type, indent int) string {
+	if value, ok := r.samples.lookup(key, description, ty); ok {
+		return value
+	}
+
+	switch {
+	case ty == cty.String:
+		return `"example"`
+	case ty == cty.Number:
+		return "1"
+	case ty == cty.Bool:
+		return "false"
+	case ty == cty.DynamicPseudoType:
+		return "null"
+	case ty.IsListType() || ty.IsSetType():
+		elem := ty.ElementType()
+		if !elem.IsObjectType() {
+			return "[]"
+		}
+		return renderCollection(indent, func(inner int) string {
+			return r.renderValue("", "", elem, inner)
+		})
+	case ty.IsMapType():
+		elem := ty.ElementType()
+		if !elem.IsObjectType() {
+			return "{}"
+		}
+		return renderMapEntry(indent, func(inner int) string {
+			return r.renderValue("", "", elem, inner)
+		})
+	case ty.IsObjectType():
+		return r.renderObject(ty, indent)
+	case ty.IsTupleType():
+		elems := ty.TupleElementTypes()
+		parts := make([]string, len(elems))
+		for i, elem := range elems {
+			parts[i] = r.renderValue("", "", elem, indent)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		log.Printf("unhandled attribute type: %s", ty.FriendlyName())
+		return "null"
+	}
+}
+
+func (r skeletonRenderer) renderObject(ty cty.Type, indent int) string {
+	var out strings.Builder
+	indentStr := strings.Repeat("\t", indent)
+	attrTypes := ty.AttributeTypes()
+
+	if len(attrTypes) == 0 {
+		return "{}"
+	}
+
+	out.WriteString("{\n")
+	for _, key := range util.SortedKeys(attrTypes) {
+		out.WriteString(fmt.Sprintf("%s\t%s = %s\n", indentStr, key, r.renderValue(key, "", attrTypes[key], indent+1)))
+	}
+	out.WriteString(indentStr + "}")
+
+	return out.String()
+}
+
+func renderCollection(indent int, elem func(indent int) string) string {
+	indentStr := strings.Repeat("\t", indent)
+	return fmt.Sprintf("[\n%s\t%s,\n%s]", indentStr, elem(indent+1), indentStr)
+}
+
+func renderMapEntry(indent int, elem func(indent int) string) string {
+	indentStr := strings.Repeat("\t", indent)
+	return fmt.Sprintf("{\n%s\tkey = %s\n%s}", indentStr, elem(indent+1), indentStr)
+}
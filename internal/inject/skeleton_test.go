@@ -0,0 +1,311 @@
+package inject
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var skeletonTestSchemas = map[string]*tfjson.Schema{
+	"primitives": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"id":         {AttributeType: cty.String, Computed: true},
+				"name":       {AttributeType: cty.String, Required: true},
+				"enabled":    {AttributeType: cty.Bool, Optional: true},
+				"size":       {AttributeType: cty.Number, Optional: true},
+				"arn":        {AttributeType: cty.String, Computed: true},
+				"legacy":     {AttributeType: cty.String, Optional: true, Deprecated: true},
+				"dynamic":    {AttributeType: cty.DynamicPseudoType, Optional: true},
+				"untyped":    {Optional: true},
+				"created_at": {AttributeType: cty.String, Computed: true},
+			},
+		},
+	},
+	"collections": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"names":   {AttributeType: cty.List(cty.String), Required: true},
+				"ports":   {AttributeType: cty.Set(cty.Number), Optional: true},
+				"tags":    {AttributeType: cty.Map(cty.String), Optional: true},
+				"matrix":  {AttributeType: cty.List(cty.List(cty.Number)), Optional: true},
+				"pair":    {AttributeType: cty.Tuple([]cty.Type{cty.String, cty.Number}), Optional: true},
+				"options": {AttributeType: cty.Object(map[string]cty.Type{"key": cty.String, "count": cty.Number}), Optional: true},
+				"rules": {
+					AttributeType: cty.List(cty.Object(map[string]cty.Type{
+						"cidrs": cty.Set(cty.String),
+						"port":  cty.Number,
+					})),
+					Optional: true,
+				},
+				"labelled": {
+					AttributeType: cty.Map(cty.Object(map[string]cty.Type{"value": cty.String})),
+					Optional:      true,
+				},
+				"empty": {AttributeType: cty.EmptyObject, Optional: true},
+			},
+		},
+	},
+	"nested_attributes": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"single": {
+					AttributeNestedType: &tfjson.SchemaNestedAttributeType{
+						NestingMode: tfjson.SchemaNestingModeSingle,
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"name":   {AttributeType: cty.String, Required: true},
+							"status": {AttributeType: cty.String, Computed: true},
+						},
+					},
+					Required: true,
+				},
+				"list": {
+					AttributeNestedType: &tfjson.SchemaNestedAttributeType{
+						NestingMode: tfjson.SchemaNestingModeList,
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"port": {AttributeType: cty.Number, Required: true},
+							"inner": {
+								AttributeNestedType: &tfjson.SchemaNestedAttributeType{
+									NestingMode: tfjson.SchemaNestingModeSet,
+									Attributes: map[string]*tfjson.SchemaAttribute{
+										"value": {AttributeType: cty.Bool, Optional: true},
+									},
+								},
+								Optional: true,
+							},
+						},
+					},
+					Optional: true,
+				},
+				"map": {
+					AttributeNestedType: &tfjson.SchemaNestedAttributeType{
+						NestingMode: tfjson.SchemaNestingModeMap,
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"values": {AttributeType: cty.Map(cty.String), Optional: true},
+						},
+					},
+					Optional: true,
+				},
+			},
+		},
+	},
+	"nested_blocks": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"name": {AttributeType: cty.String, Required: true},
+			},
+			NestedBlocks: map[string]*tfjson.SchemaBlockType{
+				"rule": {
+					NestingMode: tfjson.SchemaNestingModeList,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"action": {AttributeType: cty.String, Required: true},
+							"ids":    {AttributeType: cty.Set(cty.String), Optional: true},
+						},
+						NestedBlocks: map[string]*tfjson.SchemaBlockType{
+							"condition": {
+								NestingMode: tfjson.SchemaNestingModeSingle,
+								Block: &tfjson.SchemaBlock{
+									Attributes: map[string]*tfjson.SchemaAttribute{
+										"expression": {AttributeType: cty.String, Required: true},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+	"documented": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"id":  {AttributeType: cty.String, Computed: true},
+				"arn": {AttributeType: cty.String, Computed: true, Description: "ARN of the bucket."},
+				"bucket": {
+					AttributeType: cty.String,
+					Required:      true,
+					Description:   "Name of the bucket. Changing this forces a new resource to be created.",
+				},
+				"password": {
+					AttributeType: cty.String,
+					Optional:      true,
+					Sensitive:     true,
+					Description:   "Admin password.\nMust be at least 8 characters.",
+				},
+				"region": {AttributeType: cty.String, Optional: true, Computed: true, Description: "Region to create the bucket in."},
+			},
+			NestedBlocks: map[string]*tfjson.SchemaBlockType{
+				"origin": {
+					NestingMode: tfjson.SchemaNestingModeList,
+					MinItems:    2,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"domain": {AttributeType: cty.String, Required: true, Description: "Origin domain."},
+							"port":   {AttributeType: cty.Number, Optional: true},
+						},
+					},
+				},
+				"logging": {
+					NestingMode: tfjson.SchemaNestingModeList,
+					MaxItems:    1,
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"target": {AttributeType: cty.String, Required: true},
+						},
+					},
+				},
+			},
+		},
+	},
+	"sampled": {
+		Block: &tfjson.SchemaBlock{
+			Attributes: map[string]*tfjson.SchemaAttribute{
+				"engine":        {AttributeType: cty.String, Required: true, Description: "Database engine. Valid values are `postgres`, `mysql`."},
+				"storage_type":  {AttributeType: cty.String, Optional: true, Description: "Storage type, one of: `gp2`, `io1`."},
+				"retention":     {AttributeType: cty.Number, Optional: true, Description: "Backup retention. Defaults to `7`."},
+				"role_arn":      {AttributeType: cty.String, Required: true},
+				"subnet_ids":    {AttributeType: cty.Set(cty.String), Required: true},
+				"vpc_id":        {AttributeType: cty.String, Required: true},
+				"instance_type": {AttributeType: cty.String, Required: true},
+				"region":        {AttributeType: cty.String, Optional: true},
+				"tags":          {AttributeType: cty.Map(cty.String), Optional: true},
+			},
+		},
+	},
+}
+
+func TestBuildSkeletonGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		schema string
+		opts   SkeletonOptions
+	}{
+		{golden: "primitives", schema: "primitives"},
+		{golden: "collections", schema: "collections"},
+		{golden: "nested_attributes", schema: "nested_attributes"},
+		{golden: "nested_blocks", schema: "nested_blocks"},
+		{golden: "documented", schema: "documented"},
+		{golden: "documented_required_only", schema: "documented", opts: SkeletonOptions{RequiredOnly: true}},
+		{golden: "documented_with_docs", schema: "documented", opts: SkeletonOptions{WithDocs: true}},
+		{golden: "documented_all", schema: "documented", opts: SkeletonOptions{All: true}},
+		{golden: "nested_attributes_required_only", schema: "nested_attributes", opts: SkeletonOptions{RequiredOnly: true}},
+		{golden: "sampled", schema: "sampled"},
+		{golden: "sampled_user_rules", schema: "sampled", opts: SkeletonOptions{SampleRules: []SampleRule{
+			{Attribute: "instance_type", Resource: "test_*", Value: `"t3.micro"`},
+			{Attribute: "engine", Value: `"aurora"`},
+			{Attribute: "region", Resource: "other_*", Value: `"eu-west-1"`},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got := buildSkeleton(skeletonTestSchemas[tt.schema], "test_"+tt.schema, tt.opts)
+			if _, diags := hclsyntax.ParseConfig([]byte(got), tt.golden+".tf", hcl.InitialPos); diags.HasErrors() {
+				t.Fatalf("Skeleton is not valid HCL: %v\n%s", diags, got)
+			}
+			assertGolden(t, filepath.Join("testdata", "skeleton", tt.golden+".golden"), got)
+		})
+	}
+}
+
+func assertGolden(t *testing.T, path, got string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("Output mismatch for %s:\n--- got ---\n%s\n--- want ---\n%s", path, got, string(want))
+	}
+}
+
+func TestLoadSampleRules(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	rules, err := LoadSampleRules(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadSampleRules should not fail without a rules file: %v", err)
+	}
+	if len(rules) != 0 {
+		t.Errorf("Expected no rules, got %d", len(rules))
+	}
+
+	content := `
+rules:
+  - attribute: "*_type"
+    resource: "aws_*"
+    value: '"t3.micro"'
+`
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".tfsnap"), 0755); err != nil {
+		t.Fatalf("Failed to create .tfsnap dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".tfsnap", sampleRulesFile), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write rules file: %v", err)
+	}
+
+	rules, err = LoadSampleRules(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadSampleRules failed: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Value != `"t3.micro"` || rules[0].Resource != "aws_*" {
+		t.Errorf("Unexpected rules: %+v", rules)
+	}
+}
+
+func TestValueFromDescriptionEscapesTemplates(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"Valid values are `standard`, `premium`.", `"standard"`},
+		{"Defaults to `${path.module}/out`.", `"$${path.module}/out"`},
+		{"Defaults to `%{if x}y%{endif}`.", `"%%{if x}y%%{endif}"`},
+		{"Defaults to `a \"b\" c`.", `"a \"b\" c"`},
+	}
+	for _, tt := range tests {
+		got, ok := valueFromDescription(tt.description, cty.String)
+		if !ok || got != tt.want {
+			t.Errorf("%q: expected %s, got %s (%t)", tt.description, tt.want, got, ok)
+		}
+	}
+}
+
+func TestBuiltinSampleRulesScopedToProvider(t *testing.T) {
+	tests := []struct {
+		resourceType string
+		key          string
+		want         string
+	}{
+		{resourceType: "aws_iam_role_policy", key: "role_arn", want: `"arn:aws:iam::123456789012:role/example"`},
+		{resourceType: "aws_subnet", key: "availability_zone", want: `"us-east-1a"`},
+		{resourceType: "google_compute_subnetwork", key: "region", want: ""},
+		{resourceType: "azurerm_resource_group", key: "location", want: `"westeurope"`},
+		{resourceType: "google_storage_bucket", key: "location", want: ""},
+		{resourceType: "google_compute_instance", key: "network_id", want: `"example-id"`},
+	}
+	for _, tt := range tests {
+		got, _ := newSampleValues(tt.resourceType, nil, nil).lookup(tt.key, "", cty.String)
+		if got != tt.want {
+			t.Errorf("%s.%s: expected %q, got %q", tt.resourceType, tt.key, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package inject
+
+import (
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const maxSuggestions = 3
+
+// SuggestResources returns up to three resource types close to an invalid
+// name: types containing the name (or contained in it) first, then types
+// within a small edit distance, closest first.
+func SuggestResources(schema *tfjson.ProviderSchema, input string) []string {
+	if schema == nil || input == "" {
+		return nil
+	}
+	input = strings.ToLower(input)
+	maxDistance := max(2, len(input)/3)
+
+	type candidate struct {
+		name      string
+		substring bool
+		distance  int
+	}
+	var candidates []candidate
+	for name := range schema.ResourceSchemas {
+		lower := strings.ToLower(name)
+		c := candidate{name: name, distance: editDistance(input, lower)}
+		c.substring = strings.Contains(lower, input) || strings.Contains(input, lower)
+		if c.substring || c.distance <= maxDistance {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.substring != b.substring {
+			return a.substring
+		}
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		return a.name < b.name
+	})
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, c := range candidates[:min(len(candidates), maxSuggestions)] {
+		suggestions = append(suggestions, c.name)
+	}
+	return suggestions
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
@@ -0,0 +1,55 @@
+package inject
+
+import (
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"vpc", "", 3},
+		{"aws_vpc", "aws_vpc", 0},
+		{"aws_vcp", "aws_vpc", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestSuggestResources(t *testing.T) {
+	schema := &tfjson.ProviderSchema{ResourceSchemas: map[string]*tfjson.Schema{
+		"aws_vpc":                  {},
+		"aws_vpc_endpoint":         {},
+		"aws_vpc_peering":          {},
+		"aws_vpn_gateway":          {},
+		"aws_s3_bucket":            {},
+		"aws_s3_bucket_policy":     {},
+		"aws_s3_bucket_versioning": {},
+		"aws_subnet":               {},
+	}}
+
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"aws_vcp", "aws_vpc"},
+		{"aws_subnett", "aws_subnet"},
+		{"aws_s3_bucket_polic", "aws_s3_bucket_policy,aws_s3_bucket"},
+		{"aws_vpc_endpoints", "aws_vpc_endpoint,aws_vpc"},
+		{"aws_zzzzzzzzzz", ""},
+	}
+	for _, c := range cases {
+		if got := strings.Join(SuggestResources(schema, c.input), ","); got != c.want {
+			t.Errorf("SuggestResources(%q) = %s, want %s", c.input, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Mode int
+
+const (
+	Shared Mode = iota
+	Exclusive
+)
+
+func (m Mode) String() string {
+	if m == Exclusive {
+		return "exclusive"
+	}
+	return "shared"
+}
+
+const (
+	lockFileName = "lock"
+	holdersDir   = "locks"
+	pollInterval = 100 * time.Millisecond
+)
+
+var errWouldBlock = errors.New("lock is held by another process")
+
+// Holder describes a process holding the workspace lock.
+type Holder struct {
+	PID     int       `json:"pid"`
+	Command string    `json:"command"`
+	Mode    string    `json:"mode"`
+	Since   time.Time `json:"since"`
+}
+
+// Lock is an advisory lock on a .tfsnap directory. Any number of processes
+// may hold it shared; an exclusive holder excludes everyone else. The OS
+// releases it if the process dies.
+type Lock struct {
+	file       *os.File
+	holderPath string
+	dir        string
+	command    string
+	mode       Mode
+	timeout    time.Duration
+}
+
+// Annotation is the cobra command annotation declaring the lock mode a
+// command runs under; commands without it hold the workspace exclusively.
+const Annotation = "lock"
+
+// workspace is the lock this process holds on its workspace.
+var workspace *Lock
+
+// Hold makes l the workspace lock that Exclusively switches.
+func Hold(l *Lock) {
+	workspace = l
+}
+
+// Exclusively runs fn holding the workspace lock exclusively. Interactive
+// commands run under a shared lock and only shut other processes out while
+// they write; the lock returns to shared afterwards. Without a shared
+// workspace lock fn simply runs.
+func Exclusively(fn func() error) error {
+	l := workspace
+	if l == nil || l.file == nil || l.mode == Exclusive {
+		return fn()
+	}
+
+	if err := l.relock(Exclusive); err != nil {
+		if lockErr := l.lock(Shared); lockErr != nil {
+			log.Printf("failed to retake shared workspace lock: %v", lockErr)
+		}
+		return err
+	}
+	err := fn()
+	if relockErr := l.relock(Shared); relockErr != nil && err == nil {
+		err = relockErr
+	}
+	return err
+}
+
+// Acquire takes the lock on dir in the given mode, retrying until timeout.
+// command is recorded so that waiting processes can report who holds it.
+func Acquire(dir string, mode Mode, timeout time.Duration, command string) (*Lock, error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	l := &Lock{file: f, dir: dir, command: command, timeout: timeout}
+	if err := l.lock(mode); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// lock waits for the lock file in mode and records this process as holder.
+func (l *Lock) lock(mode Mode) error {
+	deadline := time.Now().Add(l.timeout)
+	for {
+		err := tryLock(l.file, mode)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errWouldBlock) {
+			return fmt.Errorf("failed to lock workspace: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return lockedError(l.dir, l.timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+
+	l.mode = mode
+	if mode == Exclusive {
+		// nobody else holds the lock, so any records left behind are stale
+		clearHolders(l.dir)
+	}
+	if err := l.recordHolder(l.dir, mode, l.command); err != nil {
+		log.Printf("failed to record lock holder: %v", err)
+	}
+	return nil
+}
+
+// relock switches a held lock to mode. The switch is not atomic: another
+// process may take the lock in between, in which case relock waits for it.
+func (l *Lock) relock(mode Mode) error {
+	if err := unlock(l.file); err != nil {
+		return fmt.Errorf("failed to unlock workspace: %w", err)
+	}
+	return l.lock(mode)
+}
+
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	if l.holderPath != "" {
+		if err := os.Remove(l.holderPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove lock holder record: %v", err)
+		}
+	}
+
+	err := unlock(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
+
+func (l *Lock) recordHolder(dir string, mode Mode, command string) error {
+	holders := filepath.Join(dir, holdersDir)
+	if err := os.MkdirAll(holders, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Holder{
+		PID:     os.Getpid(),
+		Command: command,
+		Mode:    mode.String(),
+		Since:   time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(holders, fmt.Sprintf("%d.json", os.Getpid()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	l.holderPath = path
+	return nil
+}
+
+// Holders returns the recorded holders of the lock on dir.
+func Holders(dir string) []Holder {
+	files, err := os.ReadDir(filepath.Join(dir, holdersDir))
+	if err != nil {
+		return nil
+	}
+
+	var holders []Holder
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, holdersDir, f.Name()))
+		if err != nil {
+			continue
+		}
+		var h Holder
+		if err := json.Unmarshal(data, &h); err != nil {
+			continue
+		}
+		holders = append(holders, h)
+	}
+	return holders
+}
+
+func clearHolders(dir string) {
+	if err := os.RemoveAll(filepath.Join(dir, holdersDir)); err != nil {
+		log.Printf("failed to clear stale lock holders: %v", err)
+	}
+}
+
+func lockedError(dir string, timeout time.Duration) error {
+	holders := Holders(dir)
+	if len(holders) == 0 {
+		return fmt.Errorf("workspace is locked by another tfsnap process (waited %s)", timeout)
+	}
+
+	descriptions := make([]string, 0, len(holders))
+	for _, h := range holders {
+		descriptions = append(descriptions, fmt.Sprintf("PID %d running `%s`", h.PID, h.Command))
+	}
+	return fmt.Errorf("workspace is locked by %s (waited %s)", strings.Join(descriptions, ", "), timeout)
+}
@@ -0,0 +1,126 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSharedLocksCoexist(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := Acquire(dir, Shared, time.Second, "tfsnap cache list")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer first.Release()
+
+	second, err := Acquire(dir, Shared, time.Second, "tfsnap cache list")
+	if err != nil {
+		t.Fatalf("Second shared Acquire failed: %v", err)
+	}
+	defer second.Release()
+}
+
+func TestExclusiveLockBlocks(t *testing.T) {
+	dir := t.TempDir()
+
+	held, err := Acquire(dir, Exclusive, time.Second, "tfsnap inject aws_vpc")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	for _, mode := range []Mode{Shared, Exclusive} {
+		_, err := Acquire(dir, mode, 200*time.Millisecond, "tfsnap snapshot save")
+		if err == nil {
+			t.Fatalf("Expected %s Acquire to time out", mode)
+		}
+		want := fmt.Sprintf("locked by PID %d running `tfsnap inject aws_vpc`", os.Getpid())
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %q", want, err)
+		}
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if len(Holders(dir)) != 0 {
+		t.Errorf("Expected no holders after release, got %+v", Holders(dir))
+	}
+
+	next, err := Acquire(dir, Exclusive, time.Second, "tfsnap snapshot save")
+	if err != nil {
+		t.Fatalf("Acquire after release failed: %v", err)
+	}
+	next.Release()
+}
+
+func TestExclusiveLockWaitsForRelease(t *testing.T) {
+	dir := t.TempDir()
+
+	held, err := Acquire(dir, Shared, time.Second, "tfsnap cache list")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		held.Release()
+	}()
+
+	l, err := Acquire(dir, Exclusive, 5*time.Second, "tfsnap inject")
+	if err != nil {
+		t.Fatalf("Expected exclusive Acquire to succeed once released: %v", err)
+	}
+	l.Release()
+}
+
+func TestExclusivelyUpgradesSharedLock(t *testing.T) {
+	dir := t.TempDir()
+
+	held, err := Acquire(dir, Shared, 200*time.Millisecond, "tfsnap snapshot")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer held.Release()
+	Hold(held)
+	defer Hold(nil)
+
+	err = Exclusively(func() error {
+		if _, err := Acquire(dir, Shared, 200*time.Millisecond, "tfsnap cache list"); err == nil {
+			t.Error("Expected shared Acquire to time out while writing")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Exclusively failed: %v", err)
+	}
+
+	other, err := Acquire(dir, Shared, time.Second, "tfsnap cache list")
+	if err != nil {
+		t.Fatalf("Expected the lock to be shared again: %v", err)
+	}
+
+	// another reader keeps the workspace from being written
+	ran := false
+	if err := Exclusively(func() error { ran = true; return nil }); err == nil || ran {
+		t.Errorf("Expected Exclusively to time out while shared, got %v (ran %t)", err, ran)
+	}
+	other.Release()
+}
+
+func TestProcessAlive(t *testing.T) {
+	if !ProcessAlive(os.Getpid()) {
+		t.Error("Expected the test process to be alive")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to run child process: %v", err)
+	}
+	if ProcessAlive(cmd.Process.Pid) {
+		t.Errorf("Expected exited process %d not to be alive", cmd.Process.Pid)
+	}
+}
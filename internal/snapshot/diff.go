@@ -0,0 +1,92 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/crypt"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+// ConfigFiles returns the terraform files of a snapshot keyed by their
+// slash-separated path in the working directory. Without a terminal to prompt
+// on, encrypted files are only readable with a key file or TFSNAP_PASSPHRASE.
+func ConfigFiles(cfg *config.Config, name string) (map[string][]byte, error) {
+	files, err := readSnapshotFiles(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var c *crypt.Cipher
+	contents := make(map[string][]byte)
+	for _, rel := range filesUnder(files, snapshotTFConfigFileDir) {
+		data, err := os.ReadFile(files[path.Join(snapshotTFConfigFileDir, rel)].Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		if crypt.IsEncrypted(data) {
+			if c == nil {
+				if cfg.Encryption.KeyFile == "" && os.Getenv(crypt.PassphraseEnv) == "" {
+					return nil, fmt.Errorf("snapshot %s is encrypted; set %s or configure a key file to read it here", name, crypt.PassphraseEnv)
+				}
+				if c, err = newCipher(cfg, false); err != nil {
+					return nil, err
+				}
+			}
+			if data, err = c.Decrypt(data); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+			}
+		}
+		contents[rel] = data
+	}
+	return contents, nil
+}
+
+// DiffWorking returns a unified diff from the snapshot's terraform files to
+// the tracked files in the working directory, or "" when they match.
+func DiffWorking(cfg *config.Config, name string) (string, error) {
+	snapshotFiles, err := ConfigFiles(cfg, name)
+	if err != nil {
+		return "", err
+	}
+
+	rules, err := util.LoadFileRules(cfg)
+	if err != nil {
+		return "", err
+	}
+	workingFiles, err := sortedFiles(rules, cfg.WorkingDirectory)
+	if err != nil {
+		return "", fmt.Errorf("failed to read working directory: %w", err)
+	}
+
+	paths := make(map[string]bool)
+	for rel := range snapshotFiles {
+		paths[rel] = true
+	}
+	for _, rel := range workingFiles {
+		paths[filepath.ToSlash(rel)] = true
+	}
+	var diff strings.Builder
+	for _, rel := range util.SortedKeys(paths) {
+		var working []byte
+		if containsSorted(workingFiles, filepath.FromSlash(rel)) {
+			if working, err = os.ReadFile(filepath.Join(cfg.WorkingDirectory, filepath.FromSlash(rel))); err != nil {
+				return "", fmt.Errorf("failed to read %s: %w", rel, err)
+			}
+		}
+
+		aName, bName := "snapshot/"+rel, "working/"+rel
+		if _, ok := snapshotFiles[rel]; !ok {
+			aName = "/dev/null"
+		}
+		if working == nil {
+			bName = "/dev/null"
+		}
+		diff.WriteString(util.UnifiedDiff(aName, bName, string(snapshotFiles[rel]), string(working)))
+	}
+	return diff.String(), nil
+}
@@ -0,0 +1,140 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+func validateName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || isInternalDir(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid snapshot name: %q", name)
+	}
+	return nil
+}
+
+// updateMetadata applies update to a snapshot's metadata and replaces the
+// metadata file in one step so it is never seen half written.
+func updateMetadata(cfg *config.Config, name string, update func(*Metadata)) (*Metadata, error) {
+	snapshotDir := filepath.Join(cfg.SnapshotDirectory, name)
+	metadata, err := readMetadata(filepath.Join(snapshotDir, snapshotConfigFile))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	}
+	update(metadata)
+
+	tmp, err := os.CreateTemp(snapshotDir, ".metadata-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to update metadata: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := writeJSON(tmp.Name(), metadata); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(snapshotDir, snapshotConfigFile)); err != nil {
+		return nil, fmt.Errorf("failed to update metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// AddTags adds tags to a saved snapshot without rebuilding it.
+func AddTags(cfg *config.Config, name string, tags []string) (*Metadata, error) {
+	return updateMetadata(cfg, name, func(md *Metadata) {
+		md.Tags = mergeTags(md.Tags, tags)
+	})
+}
+
+func SetDescription(cfg *config.Config, name, description string) (*Metadata, error) {
+	return updateMetadata(cfg, name, func(md *Metadata) {
+		md.Description = strings.TrimSpace(description)
+	})
+}
+
+// RenameSnapshot moves a snapshot to a new name. Its files stay in the object
+// store, so only the snapshot directory and its metadata change.
+func RenameSnapshot(cfg *config.Config, name, newName string) error {
+	if err := validateName(newName); err != nil {
+		return err
+	}
+	source := filepath.Join(cfg.SnapshotDirectory, name)
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+	target := filepath.Join(cfg.SnapshotDirectory, newName)
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("snapshot already exists: %s", newName)
+	}
+
+	if err := os.Rename(source, target); err != nil {
+		return fmt.Errorf("failed to rename snapshot: %w", err)
+	}
+	if _, err := updateMetadata(cfg, newName, func(md *Metadata) { md.Id = newName }); err != nil {
+		if restoreErr := os.Rename(target, source); restoreErr != nil {
+			return fmt.Errorf("%w (and failed to restore %s: %v)", err, name, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// DuplicateSnapshot copies a snapshot under a new name. The copy shares the
+// original's objects; snapshots in the legacy layout are moved into the object
+// store on the way.
+func DuplicateSnapshot(cfg *config.Config, name, newName string) (*Metadata, error) {
+	if err := validateName(newName); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(cfg.SnapshotDirectory, newName)); err == nil {
+		return nil, fmt.Errorf("snapshot already exists: %s", newName)
+	}
+
+	metadata, err := readMetadata(filepath.Join(cfg.SnapshotDirectory, name, snapshotConfigFile))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	}
+	files, err := readSnapshotFiles(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	stagingDir, err := newStagingDir(cfg, newName)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	m := &manifest{}
+	for _, rel := range util.SortedKeys(files) {
+		file := files[rel]
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot %s: %w", name, err)
+		}
+		hash, err := storeFile(cfg, file.Path, file.Hash, false)
+		if err != nil {
+			return nil, err
+		}
+		m.add(rel, hash, info.Size(), file.Mode)
+	}
+	if err := writeJSON(filepath.Join(stagingDir, snapshotManifestFile), m); err != nil {
+		return nil, err
+	}
+
+	metadata.Id = newName
+	metadata.CreatedAt = time.Now()
+	metadata.ModifiedAt = metadata.CreatedAt
+	if err := writeMetadata(stagingDir, metadata); err != nil {
+		return nil, err
+	}
+	if err := commitStaging(cfg, stagingDir, newName); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
@@ -0,0 +1,134 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phergul/tfsnap/internal/crypt"
+)
+
+func TestRenameAndDescribeSnapshot(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "snap", "first", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if _, err := BuildSnapshot(cfg, "other", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+
+	if err := RenameSnapshot(cfg, "snap", "other"); err == nil {
+		t.Error("Expected renaming onto an existing snapshot to fail")
+	}
+	if err := RenameSnapshot(cfg, "snap", "../escape"); err == nil {
+		t.Error("Expected an invalid name to be rejected")
+	}
+	if err := RenameSnapshot(cfg, "snap", "renamed"); err != nil {
+		t.Fatalf("RenameSnapshot failed: %v", err)
+	}
+	if _, err := SetDescription(cfg, "renamed", "  second  "); err != nil {
+		t.Fatalf("SetDescription failed: %v", err)
+	}
+
+	md, err := readMetadata(filepath.Join(cfg.SnapshotDirectory, "renamed", snapshotConfigFile))
+	if err != nil {
+		t.Fatalf("Failed to read metadata: %v", err)
+	}
+	if md.Id != "renamed" || md.Description != "second" {
+		t.Errorf("Expected renamed snapshot with new description, got %+v", md)
+	}
+	if _, err := PlanLoad(cfg, "renamed", false); err != nil {
+		t.Errorf("Expected renamed snapshot to load: %v", err)
+	}
+}
+
+func TestDuplicateSnapshot(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	original, err := BuildSnapshot(cfg, "snap", "first", BuildOptions{Tags: []string{"base"}})
+	if err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	objects := countObjects(t, cfg)
+
+	copied, err := DuplicateSnapshot(cfg, "snap", "copy")
+	if err != nil {
+		t.Fatalf("DuplicateSnapshot failed: %v", err)
+	}
+	if copied.Id != "copy" || copied.Description != "first" || !copied.CreatedAt.After(original.CreatedAt) {
+		t.Errorf("Unexpected copy metadata: %+v", copied)
+	}
+	if got := countObjects(t, cfg); got != objects {
+		t.Errorf("Expected the copy to share objects, got %d objects instead of %d", got, objects)
+	}
+
+	// the copy must survive deleting the original
+	if err := DeleteSnapshot(cfg, "snap"); err != nil {
+		t.Fatalf("DeleteSnapshot failed: %v", err)
+	}
+	files, err := ConfigFiles(cfg, "copy")
+	if err != nil {
+		t.Fatalf("ConfigFiles failed: %v", err)
+	}
+	if !strings.Contains(string(files["main.tf"]), "aws_vpc") {
+		t.Errorf("Unexpected copied main.tf: %s", files["main.tf"])
+	}
+}
+
+func TestDiffWorking(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if diff, err := DiffWorking(cfg, "snap"); err != nil || diff != "" {
+		t.Fatalf("Expected no diff right after saving, got %q (%v)", diff, err)
+	}
+
+	mainTf := filepath.Join(cfg.WorkingDirectory, "main.tf")
+	data, err := os.ReadFile(mainTf)
+	if err != nil {
+		t.Fatalf("Failed to read main.tf: %v", err)
+	}
+	if err := os.WriteFile(mainTf, []byte(strings.Replace(string(data), "10.0.0.0/16", "10.1.0.0/16", 1)), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.WorkingDirectory, "extra.tf"), []byte("# extra\n"), 0644); err != nil {
+		t.Fatalf("Failed to write extra.tf: %v", err)
+	}
+
+	diff, err := DiffWorking(cfg, "snap")
+	if err != nil {
+		t.Fatalf("DiffWorking failed: %v", err)
+	}
+	for _, want := range []string{
+		"--- snapshot/main.tf\n+++ working/main.tf\n",
+		`-  cidr_block = "10.0.0.0/16"`,
+		`+  cidr_block = "10.1.0.0/16"`,
+		"--- /dev/null\n+++ working/extra.tf\n",
+		"+# extra",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Expected diff to contain %q, got:\n%s", want, diff)
+		}
+	}
+}
+
+func TestConfigFilesNeedsPassphraseWithoutPrompt(t *testing.T) {
+	cfg := newTestWorkspace(t)
+	t.Setenv(crypt.PassphraseEnv, "correct horse")
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{Encrypt: true}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if _, err := ConfigFiles(cfg, "snap"); err != nil {
+		t.Errorf("Expected ConfigFiles to decrypt with %s set: %v", crypt.PassphraseEnv, err)
+	}
+
+	t.Setenv(crypt.PassphraseEnv, "")
+	if _, err := ConfigFiles(cfg, "snap"); err == nil || !strings.Contains(err.Error(), "encrypted") {
+		t.Errorf("Expected an encrypted snapshot error, got %v", err)
+	}
+}
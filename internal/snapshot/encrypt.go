@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/crypt"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+// newCipher builds a cipher from the configured key file, or asks for the
+// passphrase (twice when a new secret is being set).
+func newCipher(cfg *config.Config, confirm bool) (*crypt.Cipher, error) {
+	var passphrase string
+	var err error
+	if keyFile := cfg.Encryption.KeyFile; keyFile != "" {
+		if !filepath.IsAbs(keyFile) {
+			keyFile = filepath.Join(cfg.WorkingDirectory, keyFile)
+		}
+		passphrase, err = crypt.ReadKeyFile(keyFile)
+	} else {
+		passphrase, err = crypt.PromptPassphrase(confirm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return crypt.New(passphrase)
+}
+
+// encryptDir encrypts every file under dir in place, keeping file names and
+// modes.
+func encryptDir(dir string, c *crypt.Cipher) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		encrypted, err := c.Encrypt(data)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", path, err)
+		}
+		return os.WriteFile(path, encrypted, info.Mode().Perm())
+	})
+}
+
+// verifyCipher checks that c decrypts the first encrypted file of an existing
+// snapshot.
+func verifyCipher(files map[string]snapshotFile, c *crypt.Cipher) error {
+	for _, rel := range util.SortedKeys(files) {
+		data, err := os.ReadFile(files[rel].Path)
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		if crypt.IsEncrypted(data) {
+			_, err = c.Decrypt(data)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phergul/tfsnap/internal/crypt"
+)
+
+func TestEncryptedSnapshotWithKeyFile(t *testing.T) {
+	cfg := newTestWorkspace(t)
+	if err := os.WriteFile(filepath.Join(cfg.WorkingDirectory, "snapshot.key"), []byte("secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	cfg.Encryption.KeyFile = "snapshot.key"
+	writeTestState(t, cfg.WorkingDirectory, testState)
+
+	metadata, err := BuildSnapshot(cfg, "snap", "", BuildOptions{IncludeState: true, Encrypt: true})
+	if err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if !metadata.Encrypted || !metadata.State.Encrypted {
+		t.Errorf("Expected config and state to be encrypted, got %+v", metadata)
+	}
+
+	stored, err := os.ReadFile(storedFilePath(t, cfg, "snap", "tfconfig/main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read stored main.tf: %v", err)
+	}
+	if !crypt.IsEncrypted(stored) || bytes.Contains(stored, []byte("aws_vpc")) {
+		t.Error("Expected stored main.tf to be encrypted")
+	}
+
+	// metadata stays readable for listing
+	names := ListSnapshotNames(cfg)
+	if len(names) != 1 || names[0] != "snap" {
+		t.Errorf("Expected encrypted snapshot to be listed, got %v", names)
+	}
+
+	plan, err := PlanLoad(cfg, "snap", false)
+	if err != nil {
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("Expected no changes against decrypted snapshot, got %s", plan.String())
+	}
+
+	if err := os.WriteFile(filepath.Join(cfg.WorkingDirectory, "main.tf"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	plan, err = PlanLoad(cfg, "snap", false)
+	if err != nil {
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+	if err := ApplyLoad(cfg, plan); err != nil {
+		t.Fatalf("ApplyLoad failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.WorkingDirectory, "main.tf"))
+	if err != nil || !bytes.Contains(data, []byte("aws_vpc")) {
+		t.Errorf("Expected decrypted main.tf to be restored, got %q (%v)", data, err)
+	}
+}
+
+func TestUpdateEncryptedSnapshotWrongPassphrase(t *testing.T) {
+	cfg := newTestWorkspace(t)
+	t.Setenv(crypt.PassphraseEnv, "secret")
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{Encrypt: true}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+
+	t.Setenv(crypt.PassphraseEnv, "wrong")
+	if _, err := UpdateSnapshot(cfg, "snap", BuildOptions{}); err == nil {
+		t.Error("Expected updating with the wrong passphrase to fail")
+	}
+	if _, err := PlanLoad(cfg, "snap", false); err == nil {
+		t.Error("Expected loading with the wrong passphrase to fail")
+	}
+
+	t.Setenv(crypt.PassphraseEnv, "secret")
+	updated, err := UpdateSnapshot(cfg, "snap", BuildOptions{})
+	if err != nil {
+		t.Fatalf("UpdateSnapshot failed: %v", err)
+	}
+	if !updated.Encrypted {
+		t.Error("Expected the snapshot to stay encrypted")
+	}
+}
@@ -0,0 +1,48 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+// ExportSnapshot writes a self-contained copy of a snapshot to
+// destDir/<name>: its metadata and every file resolved out of the object
+// store. Encrypted files are copied as they are. It returns the export path.
+func ExportSnapshot(cfg *config.Config, name, destDir string) (string, error) {
+	files, err := readSnapshotFiles(cfg, name)
+	if err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(destDir, name)
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("export target already exists: %s", target)
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	if err := util.CopyFile(filepath.Join(cfg.SnapshotDirectory, name, snapshotConfigFile), filepath.Join(target, snapshotConfigFile)); err != nil {
+		return "", fmt.Errorf("failed to export metadata: %w", err)
+	}
+	for _, rel := range util.SortedKeys(files) {
+		file := files[rel]
+		dest := filepath.Join(target, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return "", fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+		if err := util.CopyFile(file.Path, dest); err != nil {
+			return "", fmt.Errorf("failed to export %s: %w", rel, err)
+		}
+		if file.Mode != 0 {
+			if err := os.Chmod(dest, file.Mode); err != nil {
+				return "", fmt.Errorf("failed to set mode of %s: %w", rel, err)
+			}
+		}
+	}
+	return target, nil
+}
@@ -0,0 +1,60 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportSnapshot(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+
+	dest := t.TempDir()
+	path, err := ExportSnapshot(cfg, "snap", dest)
+	if err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, "tfconfig", "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read exported main.tf: %v", err)
+	}
+	if !strings.Contains(string(data), `resource "aws_vpc" "main"`) {
+		t.Errorf("Unexpected exported main.tf: %s", data)
+	}
+	if _, err := os.Stat(filepath.Join(path, snapshotConfigFile)); err != nil {
+		t.Errorf("Expected metadata in export: %v", err)
+	}
+
+	if _, err := ExportSnapshot(cfg, "snap", dest); err == nil {
+		t.Error("Expected exporting over an existing export to fail")
+	}
+}
+
+func TestAddTags(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{Tags: []string{"base"}}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if _, err := AddTags(cfg, "snap", []string{"release", "base"}); err != nil {
+		t.Fatalf("AddTags failed: %v", err)
+	}
+
+	snapshots, err := ListSnapshots(cfg)
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("ListSnapshots failed: %v (%d snapshots)", err, len(snapshots))
+	}
+	if got := strings.Join(snapshots[0].Tags, ","); got != "base,release" {
+		t.Errorf("Expected base,release, got %s", got)
+	}
+
+	if _, err := AddTags(cfg, "missing", []string{"x"}); err == nil {
+		t.Error("Expected tagging a missing snapshot to fail")
+	}
+}
@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// Filter selects snapshots by their metadata. Zero fields match everything.
+type Filter struct {
+	// Tags must all be present on a snapshot
+	Tags         []string
+	ResourceType string
+	// Version matches the detected provider version or a prefix of it,
+	// e.g. "5.80" matches "5.80.1"
+	Version string
+	// Build is "local" or "registry"
+	Build  string
+	Branch string
+	Since  time.Time
+	Until  time.Time
+}
+
+func (f Filter) Match(md *Metadata) bool {
+	for _, tag := range f.Tags {
+		if !slices.Contains(md.Tags, tag) {
+			return false
+		}
+	}
+
+	if f.ResourceType != "" {
+		if md.ConfigAnalysis == nil {
+			return false
+		}
+		if _, ok := md.ConfigAnalysis.Resources[f.ResourceType]; !ok {
+			return false
+		}
+	}
+
+	provider := md.Provider
+	if provider == nil {
+		provider = &ProviderInfo{}
+	}
+	if f.Version != "" && !matchVersion(provider.DetectedVersion, f.Version) {
+		return false
+	}
+	switch f.Build {
+	case "local":
+		if !provider.IsLocalBuild {
+			return false
+		}
+	case "registry":
+		if provider.IsLocalBuild {
+			return false
+		}
+	}
+	if f.Branch != "" && (provider.GitInfo == nil || provider.GitInfo.Branch != f.Branch) {
+		return false
+	}
+
+	if !f.Since.IsZero() && md.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && md.CreatedAt.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// matchVersion compares a version constraint from the configuration, such as
+// "~> 5.80.0", with a version or version prefix.
+func matchVersion(detected, want string) bool {
+	detected = strings.TrimLeft(detected, "~>=<! v")
+	want = strings.TrimPrefix(want, "v")
+	return detected == want || strings.HasPrefix(detected, want+".")
+}
+
+func FilterSnapshots(snapshots []*Metadata, f Filter) []*Metadata {
+	var matched []*Metadata
+	for _, md := range snapshots {
+		if f.Match(md) {
+			matched = append(matched, md)
+		}
+	}
+	return matched
+}
+
+// SortFields are the keys snapshots can be sorted by.
+var SortFields = []string{"name", "created", "modified", "resources", "version"}
+
+// SortSnapshots orders snapshots by field, ties broken by name.
+func SortSnapshots(snapshots []*Metadata, field string, reverse bool) error {
+	var compare func(a, b *Metadata) int
+	switch field {
+	case "name":
+		compare = func(a, b *Metadata) int { return 0 }
+	case "created":
+		compare = func(a, b *Metadata) int { return a.CreatedAt.Compare(b.CreatedAt) }
+	case "modified":
+		compare = func(a, b *Metadata) int { return a.ModifiedAt.Compare(b.ModifiedAt) }
+	case "resources":
+		compare = func(a, b *Metadata) int { return resourceCount(a) - resourceCount(b) }
+	case "version":
+		compare = func(a, b *Metadata) int { return compareVersions(providerVersion(a), providerVersion(b)) }
+	default:
+		return fmt.Errorf("unknown sort field %q (valid: %s)", field, strings.Join(SortFields, ", "))
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		a, b := snapshots[i], snapshots[j]
+		if reverse {
+			a, b = b, a
+		}
+		if c := compare(a, b); c != 0 {
+			return c < 0
+		}
+		return a.Id < b.Id
+	})
+	return nil
+}
+
+// compareVersions orders released versions by semver, followed by local
+// builds and snapshots without a detected version.
+func compareVersions(a, b string) int {
+	va, vb := "v"+strings.TrimLeft(a, "~>=<! v"), "v"+strings.TrimLeft(b, "~>=<! v")
+	switch validA, validB := semver.IsValid(va), semver.IsValid(vb); {
+	case validA && validB:
+		return semver.Compare(va, vb)
+	case validA:
+		return -1
+	case validB:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func resourceCount(md *Metadata) int {
+	if md.ConfigAnalysis == nil {
+		return 0
+	}
+	return md.ConfigAnalysis.TotalCount
+}
+
+func providerVersion(md *Metadata) string {
+	if md.Provider == nil {
+		return ""
+	}
+	if md.Provider.IsLocalBuild {
+		return "local"
+	}
+	return md.Provider.DetectedVersion
+}
@@ -0,0 +1,113 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func filterTestSnapshots() []*Metadata {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 12, 0, 0, 0, time.UTC) }
+	return []*Metadata{
+		{
+			Id:             "vpc-bug",
+			CreatedAt:      day(3),
+			Tags:           []string{"bug", "vpc"},
+			Provider:       &ProviderInfo{DetectedVersion: "~> 5.80.0"},
+			ConfigAnalysis: &ConfigAnalysis{Resources: map[string]Resource{"aws_vpc": {Count: 1}}, TotalCount: 1},
+		},
+		{
+			Id:        "local-build",
+			CreatedAt: day(1),
+			Tags:      []string{"bug"},
+			Provider: &ProviderInfo{
+				IsLocalBuild: true,
+				GitInfo:      &GitInfo{Branch: "feature/subnets"},
+			},
+			ConfigAnalysis: &ConfigAnalysis{Resources: map[string]Resource{"aws_subnet": {Count: 3}}, TotalCount: 3},
+		},
+		{
+			Id:        "old",
+			CreatedAt: day(2),
+			Provider:  &ProviderInfo{DetectedVersion: "5.9.0"},
+		},
+	}
+}
+
+func snapshotIds(snapshots []*Metadata) string {
+	ids := make([]string, len(snapshots))
+	for i, md := range snapshots {
+		ids[i] = md.Id
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestFilterSnapshots(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   string
+	}{
+		{"no filter", Filter{}, "vpc-bug,local-build,old"},
+		{"tag", Filter{Tags: []string{"bug"}}, "vpc-bug,local-build"},
+		{"all tags", Filter{Tags: []string{"bug", "vpc"}}, "vpc-bug"},
+		{"resource type", Filter{ResourceType: "aws_subnet"}, "local-build"},
+		{"version constraint", Filter{Version: "5.80"}, "vpc-bug"},
+		{"version prefix", Filter{Version: "v5.9"}, "old"},
+		{"local build", Filter{Build: "local"}, "local-build"},
+		{"registry build", Filter{Build: "registry"}, "vpc-bug,old"},
+		{"branch", Filter{Branch: "feature/subnets"}, "local-build"},
+		{"date range", Filter{Since: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), Until: time.Date(2025, 1, 2, 23, 0, 0, 0, time.UTC)}, "old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snapshotIds(FilterSnapshots(filterTestSnapshots(), tt.filter)); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSortSnapshots(t *testing.T) {
+	tests := []struct {
+		field   string
+		reverse bool
+		want    string
+	}{
+		{"name", false, "local-build,old,vpc-bug"},
+		{"created", false, "local-build,old,vpc-bug"},
+		{"created", true, "vpc-bug,old,local-build"},
+		{"resources", true, "local-build,vpc-bug,old"},
+		{"version", false, "old,vpc-bug,local-build"},
+	}
+
+	for _, tt := range tests {
+		snapshots := filterTestSnapshots()
+		if err := SortSnapshots(snapshots, tt.field, tt.reverse); err != nil {
+			t.Fatalf("SortSnapshots failed: %v", err)
+		}
+		if got := snapshotIds(snapshots); got != tt.want {
+			t.Errorf("Sort by %s (reverse %v): expected %s, got %s", tt.field, tt.reverse, tt.want, got)
+		}
+	}
+
+	if err := SortSnapshots(filterTestSnapshots(), "size", false); err == nil {
+		t.Error("Expected an error for an unknown sort field")
+	}
+}
+
+func TestSnapshotTagsMergeOnUpdate(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{Tags: []string{"vpc", "bug"}}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	updated, err := UpdateSnapshot(cfg, "snap", BuildOptions{Tags: []string{"bug", "fixed"}})
+	if err != nil {
+		t.Fatalf("UpdateSnapshot failed: %v", err)
+	}
+	if got := strings.Join(updated.Tags, ","); got != "bug,fixed,vpc" {
+		t.Errorf("Expected merged tags bug,fixed,vpc, got %s", got)
+	}
+}
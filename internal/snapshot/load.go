@@ -0,0 +1,232 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/crypt"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+// LoadPlan lists the changes loading a snapshot makes to the working
+// directory. Paths are relative to the working directory.
+type LoadPlan struct {
+	Snapshot  string
+	Merge     bool
+	Added     []string
+	Changed   []string
+	Removed   []string
+	Unchanged []string
+
+	// sources maps each planned path to the snapshot file it is restored from
+	sources map[string]snapshotFile
+	cfg     *config.Config
+	cipher  *crypt.Cipher
+	state   map[string]bool
+}
+
+func (p *LoadPlan) Empty() bool {
+	return len(p.Added) == 0 && len(p.Changed) == 0 && len(p.Removed) == 0
+}
+
+// Overwrites reports whether loading changes or removes existing files, so
+// the current contents are lost unless they were saved first.
+func (p *LoadPlan) Overwrites() bool {
+	return len(p.Changed) > 0 || len(p.Removed) > 0
+}
+
+// ReplacesState reports whether loading overwrites existing state files.
+func (p *LoadPlan) ReplacesState() bool {
+	for _, rel := range p.Changed {
+		if p.state[rel] {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *LoadPlan) Summary() string {
+	return fmt.Sprintf("%d added, %d changed, %d removed", len(p.Added), len(p.Changed), len(p.Removed))
+}
+
+func (p *LoadPlan) String() string {
+	var b strings.Builder
+	for _, f := range p.Added {
+		fmt.Fprintf(&b, "  + %s\n", f)
+	}
+	for _, f := range p.Changed {
+		fmt.Fprintf(&b, "  ~ %s\n", f)
+	}
+	for _, f := range p.Removed {
+		fmt.Fprintf(&b, "  - %s\n", f)
+	}
+	if p.Empty() {
+		b.WriteString("  no changes\n")
+	}
+	return b.String()
+}
+
+// PlanLoad compares a snapshot with the working directory. Unless merge is
+// set, tracked files that are not part of the snapshot are removed so the
+// working directory matches the snapshot exactly. Captured state is restored
+// but working state is never removed.
+func PlanLoad(cfg *config.Config, name string, merge bool) (*LoadPlan, error) {
+	files, err := readSnapshotFiles(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := util.LoadFileRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// everything in the snapshot was selected when it was saved; in the
+	// working directory only files covered by the rules are considered, so
+	// excluded files are never removed
+	var snapshotFiles []string
+	for _, rel := range filesUnder(files, snapshotTFConfigFileDir) {
+		snapshotFiles = append(snapshotFiles, filepath.FromSlash(rel))
+	}
+	workingFiles, err := sortedFiles(rules, cfg.WorkingDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read working directory: %w", err)
+	}
+
+	plan := &LoadPlan{Snapshot: name, Merge: merge, sources: make(map[string]snapshotFile), cfg: cfg, state: make(map[string]bool)}
+	for _, rel := range snapshotFiles {
+		source := files[path.Join(snapshotTFConfigFileDir, filepath.ToSlash(rel))]
+		if err := plan.compare(source, cfg.WorkingDirectory, rel, containsSorted(workingFiles, rel)); err != nil {
+			return nil, err
+		}
+	}
+
+	// snapshots saved before state capture existed have no state entry
+	metadata, err := readMetadata(filepath.Join(cfg.SnapshotDirectory, name, snapshotConfigFile))
+	if err == nil && metadata.State != nil {
+		for _, stateFile := range metadata.State.Files {
+			source, ok := files[path.Join(snapshotStateDir, stateFile)]
+			if !ok {
+				return nil, fmt.Errorf("snapshot %s is missing state file %s", name, stateFile)
+			}
+			rel := filepath.FromSlash(stateFile)
+			_, err := os.Stat(filepath.Join(cfg.WorkingDirectory, rel))
+			if err := plan.compare(source, cfg.WorkingDirectory, rel, err == nil); err != nil {
+				return nil, err
+			}
+			plan.state[rel] = true
+		}
+	}
+
+	if !merge {
+		for _, rel := range workingFiles {
+			if !containsSorted(snapshotFiles, rel) {
+				plan.Removed = append(plan.Removed, rel)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+func (p *LoadPlan) compare(source snapshotFile, workingDir, rel string, exists bool) error {
+	p.sources[rel] = source
+	if !exists {
+		p.Added = append(p.Added, rel)
+		return nil
+	}
+
+	data, err := p.readSource(source.Path)
+	if err != nil {
+		return err
+	}
+	current, err := os.ReadFile(filepath.Join(workingDir, rel))
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, current) {
+		p.Unchanged = append(p.Unchanged, rel)
+	} else {
+		p.Changed = append(p.Changed, rel)
+	}
+	return nil
+}
+
+// readSource returns the plaintext of a snapshot file, asking for the
+// passphrase the first time an encrypted file is read.
+func (p *LoadPlan) readSource(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !crypt.IsEncrypted(data) {
+		return data, err
+	}
+
+	if p.cipher == nil {
+		if p.cipher, err = newCipher(p.cfg, false); err != nil {
+			return nil, err
+		}
+	}
+	data, err = p.cipher.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// ApplyLoad writes the snapshot files and removes the files the plan drops.
+func ApplyLoad(cfg *config.Config, plan *LoadPlan) error {
+	for _, rel := range append(append([]string{}, plan.Added...), plan.Changed...) {
+		target := filepath.Join(cfg.WorkingDirectory, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+		source, ok := plan.sources[rel]
+		if !ok {
+			return fmt.Errorf("no snapshot file for %s", rel)
+		}
+
+		data, err := plan.readSource(source.Path)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", rel, err)
+		}
+		mode := source.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(target, data, mode); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", rel, err)
+		}
+		if err := os.Chmod(target, mode); err != nil {
+			log.Printf("failed to set mode of %s: %v", rel, err)
+		}
+	}
+
+	for _, rel := range plan.Removed {
+		if err := os.Remove(filepath.Join(cfg.WorkingDirectory, rel)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+		log.Printf("Removed %s (not in snapshot %s)", rel, plan.Snapshot)
+	}
+
+	return nil
+}
+
+func sortedFiles(rules *util.FileRules, dir string) ([]string, error) {
+	files, err := rules.Files(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func containsSorted(sorted []string, s string) bool {
+	i := sort.SearchStrings(sorted, s)
+	return i < len(sorted) && sorted[i] == s
+}
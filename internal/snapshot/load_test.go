@@ -0,0 +1,124 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+func writeLoadTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func newLoadTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	workDir := t.TempDir()
+	cfg := &config.Config{
+		WorkingDirectory:  workDir,
+		SnapshotDirectory: filepath.Join(workDir, ".tfsnap", "snapshots"),
+	}
+
+	writeLoadTestFiles(t, filepath.Join(cfg.SnapshotDirectory, "snap", snapshotTFConfigFileDir), map[string]string{
+		"main.tf":      "main-snapshot",
+		"vars.tfvars":  "vars",
+		"same.tf":      "same",
+		"mod/inner.tf": "inner",
+	})
+	writeLoadTestFiles(t, workDir, map[string]string{
+		"main.tf":                     "main-current",
+		"same.tf":                     "same",
+		"extra.tf":                    "extra",
+		"terraform.tfstate":           "state",
+		".terraform/modules/x/mod.tf": "module",
+	})
+	return cfg
+}
+
+func TestPlanLoadExact(t *testing.T) {
+	cfg := newLoadTestConfig(t)
+
+	plan, err := PlanLoad(cfg, "snap", false)
+	if err != nil {
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+
+	check := func(label string, got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected %s %v, got %v", label, want, got)
+		}
+	}
+	check("added", plan.Added, filepath.Join("mod", "inner.tf"), "vars.tfvars")
+	check("changed", plan.Changed, "main.tf")
+	check("removed", plan.Removed, "extra.tf")
+	check("unchanged", plan.Unchanged, "same.tf")
+	if !plan.Overwrites() {
+		t.Error("Expected a plan that changes and removes files to overwrite")
+	}
+
+	if err := ApplyLoad(cfg, plan); err != nil {
+		t.Fatalf("ApplyLoad failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.WorkingDirectory, "extra.tf")); !os.IsNotExist(err) {
+		t.Error("Expected extra.tf to be removed")
+	}
+	for _, kept := range []string{"terraform.tfstate", ".terraform/modules/x/mod.tf"} {
+		if _, err := os.Stat(filepath.Join(cfg.WorkingDirectory, kept)); err != nil {
+			t.Errorf("Expected untracked %s to be kept: %v", kept, err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.WorkingDirectory, "main.tf"))
+	if err != nil || string(data) != "main-snapshot" {
+		t.Errorf("Expected main.tf from snapshot, got %q (%v)", data, err)
+	}
+
+	again, err := PlanLoad(cfg, "snap", false)
+	if err != nil {
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+	if !again.Empty() {
+		t.Errorf("Expected no changes after load, got %s", again.Summary())
+	}
+	if again.Overwrites() {
+		t.Error("Expected an empty plan not to overwrite anything")
+	}
+}
+
+func TestPlanLoadMerge(t *testing.T) {
+	cfg := newLoadTestConfig(t)
+
+	plan, err := PlanLoad(cfg, "snap", true)
+	if err != nil {
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+	if len(plan.Removed) != 0 {
+		t.Errorf("Merge should not remove files, got %v", plan.Removed)
+	}
+
+	if err := ApplyLoad(cfg, plan); err != nil {
+		t.Fatalf("ApplyLoad failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.WorkingDirectory, "extra.tf")); err != nil {
+		t.Errorf("Expected extra.tf to be kept when merging: %v", err)
+	}
+}
+
+func TestPlanLoadMissingSnapshot(t *testing.T) {
+	cfg := newLoadTestConfig(t)
+	if _, err := PlanLoad(cfg, "missing", false); err == nil {
+		t.Error("PlanLoad should fail for a missing snapshot")
+	}
+}
@@ -10,7 +10,10 @@ type Metadata struct {
 	ModifiedAt     time.Time       `json:"modified_at"`
 	Provider       *ProviderInfo   `json:"provider"`
 	Description    string          `json:"description,omitempty"`
+	Tags           []string        `json:"tags,omitempty"`
 	ConfigAnalysis *ConfigAnalysis `json:"config_analysis,omitempty"`
+	State          *StateInfo      `json:"state,omitempty"`
+	Encrypted      bool            `json:"encrypted,omitempty"`
 }
 
 type ProviderInfo struct {
@@ -0,0 +1,332 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+// Snapshot files are kept once in a content-addressed object store keyed by
+// SHA-256. A snapshot directory only holds its metadata and a manifest that
+// maps snapshot paths (e.g. tfconfig/main.tf) to objects.
+const snapshotManifestFile = "manifest.json"
+
+type manifestEntry struct {
+	Path string      `json:"path"`
+	Hash string      `json:"hash"`
+	Size int64       `json:"size"`
+	Mode fs.FileMode `json:"mode"`
+}
+
+type manifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+func (m *manifest) add(rel, hash string, size int64, mode fs.FileMode) {
+	m.Files = append(m.Files, manifestEntry{Path: rel, Hash: hash, Size: size, Mode: mode.Perm()})
+}
+
+func objectsDir(cfg *config.Config) string {
+	return filepath.Join(cfg.WorkingDirectory, ".tfsnap", "objects")
+}
+
+func objectPath(cfg *config.Config, hash string) string {
+	return filepath.Join(objectsDir(cfg), hash[:2], hash)
+}
+
+// storeFile adds the file at src to the object store and returns its hash.
+// hash may be passed in when already known. With move set, src is moved into
+// the store (or removed if the object already exists) instead of copied.
+func storeFile(cfg *config.Config, src, hash string, move bool) (string, error) {
+	if hash == "" {
+		var err error
+		if hash, err = util.HashFile(src); err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", src, err)
+		}
+	}
+
+	target := objectPath(cfg, hash)
+	if _, err := os.Stat(target); err == nil {
+		if move {
+			if err := os.Remove(src); err != nil {
+				return "", fmt.Errorf("failed to remove %s: %w", src, err)
+			}
+		}
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create object directory: %w", err)
+	}
+	if move {
+		if err := os.Rename(src, target); err == nil {
+			return hash, nil
+		}
+	}
+
+	// copy next to the object first so a partial object is never visible
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create object: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := util.CopyFile(src, tmp.Name()); err != nil {
+		return "", fmt.Errorf("failed to copy %s into object store: %w", src, err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", fmt.Errorf("failed to create object: %w", err)
+	}
+	if move {
+		if err := os.Remove(src); err != nil {
+			return "", fmt.Errorf("failed to remove %s: %w", src, err)
+		}
+	}
+	return hash, nil
+}
+
+// ingestStaging moves every file written to the staging directory into the
+// object store, records it in m and writes the manifest.
+func ingestStaging(cfg *config.Config, stagingDir string, m *manifest) error {
+	err := filepath.WalkDir(stagingDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(stagingDir, p)
+		if err != nil {
+			return err
+		}
+		if rel == snapshotConfigFile || rel == snapshotManifestFile {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hash, err := storeFile(cfg, p, "", true)
+		if err != nil {
+			return err
+		}
+		m.add(filepath.ToSlash(rel), hash, info.Size(), info.Mode())
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store snapshot files: %w", err)
+	}
+
+	entries, err := os.ReadDir(stagingDir)
+	if err != nil {
+		return fmt.Errorf("failed to read staging directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			if err := os.RemoveAll(filepath.Join(stagingDir, entry.Name())); err != nil {
+				return fmt.Errorf("failed to clean staging directory: %w", err)
+			}
+		}
+	}
+
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return writeJSON(filepath.Join(stagingDir, snapshotManifestFile), m)
+}
+
+func readManifest(snapshotDir string) (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(snapshotDir, snapshotManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// snapshotFile is a file of a saved snapshot and where its content is read
+// from. Hash is empty for snapshots saved before the object store existed.
+type snapshotFile struct {
+	Path string
+	Hash string
+	Mode fs.FileMode
+}
+
+// readSnapshotFiles maps the slash-separated paths of a snapshot's files to
+// their content. Snapshots without a manifest keep their files in place.
+func readSnapshotFiles(cfg *config.Config, name string) (map[string]snapshotFile, error) {
+	snapshotDir := filepath.Join(cfg.SnapshotDirectory, name)
+	if !util.DirExists(snapshotDir) {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	}
+
+	files := make(map[string]snapshotFile)
+
+	m, err := readManifest(snapshotDir)
+	if err == nil {
+		for _, entry := range m.Files {
+			files[entry.Path] = snapshotFile{Path: objectPath(cfg, entry.Hash), Hash: entry.Hash, Mode: entry.Mode}
+		}
+		return files, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(snapshotDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(snapshotDir, p)
+		if err != nil || rel == snapshotConfigFile {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = snapshotFile{Path: p, Mode: info.Mode().Perm()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", name, err)
+	}
+	return files, nil
+}
+
+// filesUnder returns the sorted paths below dir, relative to dir.
+func filesUnder(files map[string]snapshotFile, dir string) []string {
+	var rels []string
+	for p := range files {
+		if rel, ok := strings.CutPrefix(p, dir+"/"); ok {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+// keepFiles carries files below dir over from a previous version of a
+// snapshot without copying objects already in the store.
+func keepFiles(cfg *config.Config, files map[string]snapshotFile, dir string, m *manifest) error {
+	for _, rel := range filesUnder(files, dir) {
+		file := files[path.Join(dir, rel)]
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return err
+		}
+		hash, err := storeFile(cfg, file.Path, file.Hash, false)
+		if err != nil {
+			return err
+		}
+		m.add(path.Join(dir, rel), hash, info.Size(), file.Mode)
+	}
+	return nil
+}
+
+func manifestHashes(snapshotDir string) []string {
+	m, err := readManifest(snapshotDir)
+	if err != nil {
+		return nil
+	}
+	hashes := make([]string, 0, len(m.Files))
+	for _, entry := range m.Files {
+		hashes = append(hashes, entry.Hash)
+	}
+	return hashes
+}
+
+// referencedObjects collects the objects referenced by any manifest in the
+// snapshot directory, including backups of snapshots being replaced.
+func referencedObjects(cfg *config.Config) (map[string]bool, error) {
+	refs := make(map[string]bool)
+	err := filepath.WalkDir(cfg.SnapshotDirectory, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == cfg.SnapshotDirectory {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || d.Name() != snapshotManifestFile {
+			return nil
+		}
+		for _, hash := range manifestHashes(filepath.Dir(p)) {
+			refs[hash] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot manifests: %w", err)
+	}
+	return refs, nil
+}
+
+// collectObjects removes the given objects once no snapshot references them.
+func collectObjects(cfg *config.Config, hashes []string) {
+	if len(hashes) == 0 {
+		return
+	}
+	refs, err := referencedObjects(cfg)
+	if err != nil {
+		log.Printf("failed to collect unreferenced objects: %v", err)
+		return
+	}
+
+	for _, hash := range hashes {
+		if refs[hash] {
+			continue
+		}
+		if err := os.Remove(objectPath(cfg, hash)); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove object %s: %v", hash, err)
+			continue
+		}
+		refs[hash] = true
+		log.Printf("Removed unreferenced object %s", hash[:12])
+	}
+}
+
+// CollectGarbage removes every object no snapshot references, including
+// leftovers from interrupted saves, and returns how many objects and bytes
+// were reclaimed.
+func CollectGarbage(cfg *config.Config) (int, int64, error) {
+	collectInternalDirs(cfg)
+
+	refs, err := referencedObjects(cfg)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	removed, freed := 0, int64(0)
+	root := objectsDir(cfg)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || refs[d.Name()] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf("failed to remove object %s: %w", d.Name(), err)
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	return removed, freed, err
+}
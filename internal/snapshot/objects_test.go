@@ -0,0 +1,117 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+// storedFilePath resolves a snapshot file to the object holding its content.
+func storedFilePath(t *testing.T, cfg *config.Config, name, rel string) string {
+	t.Helper()
+	files, err := readSnapshotFiles(cfg, name)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot files: %v", err)
+	}
+	file, ok := files[rel]
+	if !ok {
+		t.Fatalf("Expected %s in snapshot %s", rel, name)
+	}
+	return file.Path
+}
+
+func countObjects(t *testing.T, cfg *config.Config) int {
+	t.Helper()
+	count := 0
+	err := filepath.Walk(objectsDir(cfg), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			count++
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Failed to walk object store: %v", err)
+	}
+	return count
+}
+
+func TestSnapshotsShareObjects(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "first", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if _, err := BuildSnapshot(cfg, "second", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if n := countObjects(t, cfg); n != 1 {
+		t.Fatalf("Expected identical main.tf to be stored once, got %d objects", n)
+	}
+	if storedFilePath(t, cfg, "first", "tfconfig/main.tf") != storedFilePath(t, cfg, "second", "tfconfig/main.tf") {
+		t.Error("Expected both snapshots to reference the same object")
+	}
+
+	if err := DeleteSnapshot(cfg, "first"); err != nil {
+		t.Fatalf("DeleteSnapshot failed: %v", err)
+	}
+	if n := countObjects(t, cfg); n != 1 {
+		t.Errorf("Expected object still referenced by second to be kept, got %d objects", n)
+	}
+
+	if err := DeleteSnapshot(cfg, "second"); err != nil {
+		t.Fatalf("DeleteSnapshot failed: %v", err)
+	}
+	if n := countObjects(t, cfg); n != 0 {
+		t.Errorf("Expected unreferenced object to be removed, got %d objects", n)
+	}
+}
+
+func TestUpdateSnapshotCollectsReplacedObjects(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.WorkingDirectory, "main.tf"), []byte(`resource "aws_vpc" "other" {}`), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	if _, err := UpdateSnapshot(cfg, "snap", BuildOptions{}); err != nil {
+		t.Fatalf("UpdateSnapshot failed: %v", err)
+	}
+
+	if n := countObjects(t, cfg); n != 1 {
+		t.Errorf("Expected only the current main.tf to be stored, got %d objects", n)
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+
+	orphan := filepath.Join(cfg.WorkingDirectory, "orphan")
+	if err := os.WriteFile(orphan, []byte("left behind by an interrupted save"), 0644); err != nil {
+		t.Fatalf("Failed to write orphan: %v", err)
+	}
+	if _, err := storeFile(cfg, orphan, "", true); err != nil {
+		t.Fatalf("storeFile failed: %v", err)
+	}
+
+	removed, freed, err := CollectGarbage(cfg)
+	if err != nil {
+		t.Fatalf("CollectGarbage failed: %v", err)
+	}
+	if removed != 1 || freed != int64(len("left behind by an interrupted save")) {
+		t.Errorf("Expected 1 object and its size to be reclaimed, got %d (%d bytes)", removed, freed)
+	}
+	if n := countObjects(t, cfg); n != 1 {
+		t.Errorf("Expected the referenced object to be kept, got %d objects", n)
+	}
+}
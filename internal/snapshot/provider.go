@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -81,32 +82,9 @@ func extractNormalizedSource(source string) string {
 	return source
 }
 
-func findProviderBinary(cfg *config.Config) (string, error) {
-	if cfg.Provider.ProviderDirectory == "" {
-		return "", fmt.Errorf("provider directory not configured")
-	}
-
-	possiblePaths := []string{
-		filepath.Join(cfg.Provider.ProviderDirectory, "terraform-provider-"+cfg.Provider.Name),
-		filepath.Join(cfg.Provider.ProviderDirectory, "bin", "terraform-provider-"+cfg.Provider.Name),
-		filepath.Join(cfg.Provider.ProviderDirectory, "dist", "terraform-provider-"+cfg.Provider.Name),
-	}
-
-	for _, path := range possiblePaths {
-		if _, err := os.Stat(path); err == nil {
-			return path, nil
-		}
-	}
-
-	return "", fmt.Errorf("provider binary not found in %s", cfg.Provider.ProviderDirectory)
-}
-
-func captureProviderBinary(cfg *config.Config, binaryPath, snapshotName string, provider *ProviderInfo) error {
-	providerDir := filepath.Join(cfg.SnapshotDirectory, snapshotName, "provider")
-	if err := os.MkdirAll(providerDir, 0755); err != nil {
-		return fmt.Errorf("failed to create provider directory: %w", err)
-	}
-
+// captureProviderBinary adds the binary to the object store. A build that is
+// already stored by an earlier snapshot is not copied again.
+func captureProviderBinary(cfg *config.Config, binaryPath string, m *manifest, provider *ProviderInfo) error {
 	hash, err := util.HashFile(binaryPath)
 	if err != nil {
 		return fmt.Errorf("failed to hash binary: %w", err)
@@ -117,20 +95,16 @@ func captureProviderBinary(cfg *config.Config, binaryPath, snapshotName string,
 		return fmt.Errorf("failed to stat binary: %w", err)
 	}
 
-	binaryName := filepath.Base(binaryPath)
-	destPath := filepath.Join(providerDir, binaryName)
-
-	if err := util.CopyFile(binaryPath, destPath); err != nil {
+	if _, err := storeFile(cfg, binaryPath, hash, false); err != nil {
 		return fmt.Errorf("failed to copy binary: %w", err)
 	}
 
-	if err := os.Chmod(destPath, 0755); err != nil {
-		return fmt.Errorf("failed to make binary executable: %w", err)
-	}
+	binaryName := filepath.Base(binaryPath)
+	m.add(path.Join(snapshotProviderDir, binaryName), hash, info.Size(), 0755)
 
 	provider.Binary = &Binary{
 		OriginalPath:       binaryPath,
-		SnapshotBinaryPath: filepath.Join("provider", binaryName),
+		SnapshotBinaryPath: filepath.Join(snapshotProviderDir, binaryName),
 		Hash:               hash,
 		Size:               info.Size(),
 	}
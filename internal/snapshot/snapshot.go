@@ -6,42 +6,72 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/crypt"
 	"github.com/phergul/tfsnap/internal/util"
 )
 
 const (
 	snapshotConfigFile      = "metadata.json"
 	snapshotTFConfigFileDir = "tfconfig"
+	snapshotProviderDir     = "provider"
 )
 
-func BuildSnapshot(cfg *config.Config, name, description string, includeBinary, includeGit bool) (*Metadata, error) {
+// BuildOptions selects what a snapshot captures besides the terraform files
+// and how it is labelled.
+type BuildOptions struct {
+	IncludeBinary bool
+	IncludeGit    bool
+	IncludeState  bool
+	// EncryptState encrypts only captured state; Encrypt covers the
+	// terraform files and state alike.
+	EncryptState bool
+	Encrypt      bool
+	// Tags are added to the snapshot's existing tags
+	Tags []string
+}
+
+// BuildSnapshot captures the working directory as a new snapshot. The
+// snapshot only becomes visible once it has been written completely.
+func BuildSnapshot(cfg *config.Config, name, description string, opts BuildOptions) (*Metadata, error) {
+	collectInternalDirs(cfg)
+
 	provider, err := detectProvider(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect provider: %w", err)
 	}
-	log.Printf("Including binary: %v, Including git: %v\n", includeBinary, includeGit)
+	log.Printf("Including binary: %v, Including git: %v, Including state: %v\n", opts.IncludeBinary, opts.IncludeGit, opts.IncludeState)
 
 	configAnalysis, err := AnalyseTFConfig(cfg.WorkingDirectory)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze Terraform config: %w", err)
 	}
 
-	if includeBinary && provider.IsLocalBuild {
-		if binaryPath, err := findProviderBinary(cfg); err != nil {
+	stagingDir, err := newStagingDir(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	m := &manifest{}
+	if opts.IncludeBinary && provider.IsLocalBuild {
+		if binaryPath, err := util.FindProviderBinary(cfg); err != nil {
 			return nil, fmt.Errorf("failed to find provider binary: %w", err)
 		} else {
-			if err := captureProviderBinary(cfg, binaryPath, name, provider); err != nil {
+			if err := captureProviderBinary(cfg, binaryPath, m, provider); err != nil {
 				return nil, fmt.Errorf("failed to capture provider binary: %w", err)
 			}
 		}
-	} else if includeBinary && !provider.IsLocalBuild {
+	} else if opts.IncludeBinary && !provider.IsLocalBuild {
 		fmt.Println("Warning: Provider is not a local build; binary will not be included")
 	}
 
-	if includeGit {
+	if opts.IncludeGit {
 		log.Printf("Getting git info from provider dir: %s\n", cfg.Provider.ProviderDirectory)
 		gitInfo := getGitInfo(cfg.Provider.ProviderDirectory)
 		provider.GitInfo = gitInfo
@@ -54,36 +84,50 @@ func BuildSnapshot(cfg *config.Config, name, description string, includeBinary,
 		}
 	}
 
+	var c *crypt.Cipher
+	if opts.Encrypt || (opts.IncludeState && opts.EncryptState) {
+		if c, err = newCipher(cfg, true); err != nil {
+			return nil, err
+		}
+	}
+
+	var state *StateInfo
+	if opts.IncludeState {
+		if state, err = captureState(cfg.WorkingDirectory, stagingDir, c); err != nil {
+			return nil, fmt.Errorf("failed to capture state: %w", err)
+		}
+	}
+
 	metadata := &Metadata{
 		Id:             name,
 		CreatedAt:      time.Now(),
 		ModifiedAt:     time.Now(),
 		Provider:       provider,
 		Description:    description,
+		Tags:           mergeTags(nil, opts.Tags),
 		ConfigAnalysis: configAnalysis,
+		State:          state,
+		Encrypted:      opts.Encrypt,
 	}
 
-	metadataFilepath := filepath.Join(cfg.SnapshotDirectory, name, snapshotConfigFile)
-	if err := os.MkdirAll(filepath.Dir(metadataFilepath), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	configCipher := c
+	if !opts.Encrypt {
+		configCipher = nil
 	}
-	file, err := os.Create(metadataFilepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create %s file: %w", metadataFilepath, err)
-	}
-	defer file.Close()
-
-	if err := json.NewEncoder(file).Encode(metadata); err != nil {
-		return nil, fmt.Errorf("failed to write metadata to file: %w", err)
+	if err := finishSnapshot(cfg, stagingDir, metadata, configCipher, m); err != nil {
+		return nil, err
 	}
-
-	log.Printf("Snapshot metadata saved to %s", metadataFilepath)
 	return metadata, nil
 }
 
-func UpdateSnapshot(cfg *config.Config, name string) (*Metadata, error) {
+// UpdateSnapshot recaptures an existing snapshot from the working directory,
+// keeping its creation time, description and captured binary. State is
+// recaptured if the snapshot already held it or opts asks for it.
+func UpdateSnapshot(cfg *config.Config, name string, opts BuildOptions) (*Metadata, error) {
 	log.Println("Updating metedata for snapshot:", name)
-	metadata, err := readMetadata(filepath.Join(cfg.SnapshotDirectory, name, snapshotConfigFile))
+	collectInternalDirs(cfg)
+	snapshotDir := filepath.Join(cfg.SnapshotDirectory, name)
+	metadata, err := readMetadata(filepath.Join(snapshotDir, snapshotConfigFile))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load metadata: %w", err)
 	}
@@ -98,23 +142,38 @@ func UpdateSnapshot(cfg *config.Config, name string) (*Metadata, error) {
 		return nil, fmt.Errorf("failed to analyze Terraform config: %w", err)
 	}
 
-	binaryIncluded := false
-	if _, err := os.Stat(filepath.Join(cfg.SnapshotDirectory, name, "binary/")); err == nil && !os.IsNotExist(err) {
-		binaryIncluded = true
+	stagingDir, err := newStagingDir(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	previousFiles, err := readSnapshotFiles(cfg, name)
+	if err != nil {
+		return nil, err
 	}
+	binaryIncluded := len(filesUnder(previousFiles, snapshotProviderDir)) > 0
 
+	m := &manifest{}
 	if binaryIncluded && provider.IsLocalBuild {
-		binaryPath, err := findProviderBinary(cfg)
+		binaryPath, err := util.FindProviderBinary(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to find provider binary: %w", err)
 		} else {
-			if err := captureProviderBinary(cfg, binaryPath, name, provider); err != nil {
+			if err := captureProviderBinary(cfg, binaryPath, m, provider); err != nil {
 				return nil, fmt.Errorf("failed to capture provider binary: %w", err)
 			}
 		}
+	} else if binaryIncluded {
+		if err := keepFiles(cfg, previousFiles, snapshotProviderDir, m); err != nil {
+			return nil, fmt.Errorf("failed to keep provider binary: %w", err)
+		}
+		if metadata.Provider != nil {
+			provider.Binary = metadata.Provider.Binary
+		}
 	}
 
-	if metadata.Provider.GitInfo != nil {
+	if metadata.Provider != nil && metadata.Provider.GitInfo != nil {
 		gitInfo := getGitInfo(cfg.Provider.ProviderDirectory)
 		provider.GitInfo = gitInfo
 
@@ -126,27 +185,96 @@ func UpdateSnapshot(cfg *config.Config, name string) (*Metadata, error) {
 		}
 	}
 
+	wasEncrypted := metadata.Encrypted || (metadata.State != nil && metadata.State.Encrypted)
+	encryptConfig := opts.Encrypt || metadata.Encrypted
+	includeState := opts.IncludeState || metadata.State != nil
+	encryptState := encryptConfig || opts.EncryptState || (metadata.State != nil && metadata.State.Encrypted)
+
+	var c *crypt.Cipher
+	if encryptConfig || (includeState && encryptState) {
+		if c, err = newCipher(cfg, !wasEncrypted); err != nil {
+			return nil, err
+		}
+		// a mistyped passphrase would otherwise silently re-key the snapshot
+		if wasEncrypted {
+			if err := verifyCipher(previousFiles, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if includeState {
+		stateCipher := c
+		if !encryptState {
+			stateCipher = nil
+		}
+		if metadata.State, err = captureState(cfg.WorkingDirectory, stagingDir, stateCipher); err != nil {
+			return nil, fmt.Errorf("failed to capture state: %w", err)
+		}
+	}
+
+	metadata.Provider = provider
 	metadata.ConfigAnalysis = configAnalysis
+	metadata.Tags = mergeTags(metadata.Tags, opts.Tags)
+	metadata.Encrypted = encryptConfig
 	metadata.ModifiedAt = time.Now()
-	log.Println(time.Now())
 	log.Printf("updating metadata ModifiedAt --> %s\n", metadata.ModifiedAt.String())
 
-	metadataFilepath := filepath.Join(cfg.SnapshotDirectory, name, snapshotConfigFile)
-	if err := os.MkdirAll(filepath.Dir(metadataFilepath), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	configCipher := c
+	if !encryptConfig {
+		configCipher = nil
 	}
-	file, err := os.Create(metadataFilepath)
+	if err := finishSnapshot(cfg, stagingDir, metadata, configCipher, m); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// finishSnapshot copies the terraform files into the staging directory, moves
+// everything staged into the object store and moves the staging directory
+// into place. The terraform files are encrypted when c is set; metadata always
+// stays readable so snapshots can be listed.
+func finishSnapshot(cfg *config.Config, stagingDir string, metadata *Metadata, c *crypt.Cipher, m *manifest) error {
+	rules, err := util.LoadFileRules(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create %s file: %w", metadataFilepath, err)
+		return err
+	}
+
+	log.Println("Copying terraform files...")
+	if err := util.CopyTFFiles(cfg.WorkingDirectory, filepath.Join(stagingDir, snapshotTFConfigFileDir), rules); err != nil {
+		return fmt.Errorf("failed to copy terraform files: %w", err)
+	}
+	if c != nil {
+		if err := encryptDir(filepath.Join(stagingDir, snapshotTFConfigFileDir), c); err != nil {
+			return fmt.Errorf("failed to encrypt terraform files: %w", err)
+		}
 	}
-	defer file.Close()
 
-	if err := json.NewEncoder(file).Encode(metadata); err != nil {
-		return nil, fmt.Errorf("failed to write metadata to file: %w", err)
+	if err := ingestStaging(cfg, stagingDir, m); err != nil {
+		return err
+	}
+	if err := writeMetadata(stagingDir, metadata); err != nil {
+		return err
 	}
 
-	log.Printf("Snapshot metadata saved to %s", metadataFilepath)
-	return metadata, nil
+	previous := manifestHashes(filepath.Join(cfg.SnapshotDirectory, metadata.Id))
+	if err := commitStaging(cfg, stagingDir, metadata.Id); err != nil {
+		return err
+	}
+	collectObjects(cfg, previous)
+	log.Printf("Snapshot saved to %s", filepath.Join(cfg.SnapshotDirectory, metadata.Id))
+	return nil
+}
+
+func mergeTags(tags, add []string) []string {
+	for _, tag := range add {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
 }
 
 func ListSnapshots(cfg *config.Config) ([]*Metadata, error) {
@@ -157,6 +285,9 @@ func ListSnapshots(cfg *config.Config) ([]*Metadata, error) {
 			return err
 		}
 		if info.IsDir() {
+			if isInternalDir(info.Name()) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		if info.Name() == snapshotConfigFile {
@@ -188,30 +319,22 @@ func ListSnapshotNames(cfg *config.Config) []string {
 }
 
 func DeleteSnapshot(cfg *config.Config, name string) error {
+	collectInternalDirs(cfg)
 	snapshotDir := filepath.Join(cfg.SnapshotDirectory, name)
 
 	if _, err := os.Stat(snapshotDir); os.IsNotExist(err) {
 		return fmt.Errorf("snapshot not found: %s", name)
 	}
 
+	hashes := manifestHashes(snapshotDir)
 	if err := os.RemoveAll(snapshotDir); err != nil {
 		return fmt.Errorf("failed to delete snapshot directory: %w", err)
 	}
+	collectObjects(cfg, hashes)
 	log.Println("Snapshot deleted successfully:", name)
 	return nil
 }
 
-func LoadSnapshot(cfg *config.Config, name string) error {
-	snapshotDir := filepath.Join(cfg.SnapshotDirectory, name)
-
-	err := loadTFFiles(filepath.Join(snapshotDir, snapshotTFConfigFileDir), cfg.WorkingDirectory)
-	if err != nil {
-		return fmt.Errorf("failed to load terraform files: %w", err)
-	}
-
-	return nil
-}
-
 func ReplaceWithEmptyConfig(cfg *config.Config) error {
 	err := os.Remove(".terraform.lock.hcl")
 	if err != nil {
@@ -249,11 +372,3 @@ func readMetadata(filePath string) (*Metadata, error) {
 	}
 	return &metadata, nil
 }
-
-func CopyTerraformFiles(cfg *config.Config, metadata *Metadata) error {
-	return util.CopyTFFiles(cfg.WorkingDirectory, filepath.Join(cfg.SnapshotDirectory, metadata.Id, snapshotTFConfigFileDir), false)
-}
-
-func loadTFFiles(snapshotTerraformDir, destDir string) error {
-	return util.CopyTFFiles(snapshotTerraformDir, destDir, true)
-}
@@ -0,0 +1,177 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/lock"
+)
+
+// Snapshots are assembled in a staging directory inside SnapshotDirectory and
+// renamed into place once complete, so an interrupted save never leaves a
+// partial snapshot behind. Directories with these prefixes are never listed;
+// the prefix is followed by the PID of the process that owns them.
+const (
+	stagingPrefix = ".staging-"
+	trashPrefix   = ".trash-"
+)
+
+// minInternalDirAge is how old a leftover directory must be before it is
+// collected. SnapshotDirectory may be shared with processes on other
+// machines, whose PIDs can't be checked from here.
+const minInternalDirAge = time.Hour
+
+// processAlive is swapped in tests.
+var processAlive = lock.ProcessAlive
+
+func isInternalDir(name string) bool {
+	return strings.HasPrefix(name, stagingPrefix) || strings.HasPrefix(name, trashPrefix)
+}
+
+func newStagingDir(cfg *config.Config, name string) (string, error) {
+	if err := os.MkdirAll(cfg.SnapshotDirectory, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(cfg.SnapshotDirectory, fmt.Sprintf("%s%d-%s-", stagingPrefix, os.Getpid(), name))
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return dir, nil
+}
+
+func writeMetadata(dir string, metadata *Metadata) error {
+	return writeJSON(filepath.Join(dir, snapshotConfigFile), metadata)
+}
+
+func writeJSON(path string, v any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s file: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return file.Sync()
+}
+
+// commitStaging moves a complete staging directory to its final name. An
+// existing snapshot is moved aside first and only removed once the new one
+// is in place.
+func commitStaging(cfg *config.Config, stagingDir, name string) error {
+	target := filepath.Join(cfg.SnapshotDirectory, name)
+
+	var trash string
+	if _, err := os.Stat(target); err == nil {
+		trash = filepath.Join(cfg.SnapshotDirectory, fmt.Sprintf("%s%d-%s", trashPrefix, os.Getpid(), name))
+		if err := os.RemoveAll(trash); err != nil {
+			return fmt.Errorf("failed to clear previous snapshot backup: %w", err)
+		}
+		if err := os.Rename(target, trash); err != nil {
+			return fmt.Errorf("failed to move previous snapshot aside: %w", err)
+		}
+	}
+
+	if err := os.Rename(stagingDir, target); err != nil {
+		if trash != "" {
+			if restoreErr := os.Rename(trash, target); restoreErr != nil {
+				log.Printf("failed to restore previous snapshot %s: %v", name, restoreErr)
+			}
+		}
+		return fmt.Errorf("failed to move snapshot into place: %w", err)
+	}
+
+	if trash != "" {
+		if err := os.RemoveAll(trash); err != nil {
+			log.Printf("failed to remove previous snapshot backup: %v", err)
+		}
+	}
+	return nil
+}
+
+// internalDirOwner splits a staging or trash directory name into the PID of
+// its owner and the rest of the name.
+func internalDirOwner(name string) (int, string, bool) {
+	rest, ok := strings.CutPrefix(name, stagingPrefix)
+	if !ok {
+		if rest, ok = strings.CutPrefix(name, trashPrefix); !ok {
+			return 0, "", false
+		}
+	}
+	pidPart, rest, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, "", false
+	}
+	pid, err := strconv.Atoi(pidPart)
+	if err != nil || pid <= 0 {
+		return 0, "", false
+	}
+	return pid, rest, true
+}
+
+// abandoned reports whether a staging or trash directory can no longer be
+// in use: its owner has exited and it is older than minInternalDirAge.
+func abandoned(name string, info os.FileInfo) bool {
+	if time.Since(info.ModTime()) < minInternalDirAge {
+		return false
+	}
+	pid, _, ok := internalDirOwner(name)
+	return !ok || !processAlive(pid)
+}
+
+// collectInternalDirs garbage-collects the staging and trash directories
+// left behind by interrupted saves. It changes the snapshot directory, so it
+// only runs from commands that hold the workspace exclusively.
+func collectInternalDirs(cfg *config.Config) {
+	entries, err := os.ReadDir(cfg.SnapshotDirectory)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("failed to read snapshot directory: %v", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !isInternalDir(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !abandoned(entry.Name(), info) {
+			continue
+		}
+		collectInternalDir(cfg, entry.Name())
+	}
+}
+
+// collectInternalDir removes a leftover staging or trash directory. A trash
+// directory whose snapshot never made it back into place is restored.
+func collectInternalDir(cfg *config.Config, name string) {
+	path := filepath.Join(cfg.SnapshotDirectory, name)
+
+	if strings.HasPrefix(name, trashPrefix) {
+		if _, snapshotName, ok := internalDirOwner(name); ok {
+			target := filepath.Join(cfg.SnapshotDirectory, snapshotName)
+			if _, err := os.Stat(target); os.IsNotExist(err) {
+				if err := os.Rename(path, target); err != nil {
+					log.Printf("failed to restore snapshot %s from backup: %v", snapshotName, err)
+				} else {
+					log.Printf("Restored snapshot %s from an interrupted save", snapshotName)
+				}
+				return
+			}
+		}
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		log.Printf("failed to remove incomplete snapshot %s: %v", name, err)
+		return
+	}
+	log.Printf("Removed incomplete snapshot directory %s", name)
+}
@@ -0,0 +1,207 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+func newTestWorkspace(t *testing.T) *config.Config {
+	t.Helper()
+
+	workDir := t.TempDir()
+	mainTf := `terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+  }
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`
+	if err := os.WriteFile(filepath.Join(workDir, "main.tf"), []byte(mainTf), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+
+	return &config.Config{
+		WorkingDirectory:  workDir,
+		SnapshotDirectory: filepath.Join(workDir, ".tfsnap", "snapshots"),
+		Provider: config.Provider{
+			Name:          "aws",
+			SourceMapping: config.SourceMapping{RegistrySource: "hashicorp/aws"},
+		},
+	}
+}
+
+func assertNoInternalDirs(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	entries, err := os.ReadDir(cfg.SnapshotDirectory)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot directory: %v", err)
+	}
+	for _, e := range entries {
+		if isInternalDir(e.Name()) {
+			t.Errorf("Unexpected leftover directory %s", e.Name())
+		}
+	}
+}
+
+func TestBuildAndUpdateSnapshot(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	built, err := BuildSnapshot(cfg, "snap", "first", BuildOptions{})
+	if err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	for _, path := range []string{snapshotConfigFile, snapshotManifestFile} {
+		if _, err := os.Stat(filepath.Join(cfg.SnapshotDirectory, "snap", path)); err != nil {
+			t.Errorf("Expected %s in snapshot: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(storedFilePath(t, cfg, "snap", "tfconfig/main.tf")); err != nil {
+		t.Errorf("Expected tfconfig/main.tf in snapshot: %v", err)
+	}
+	assertNoInternalDirs(t, cfg)
+
+	if err := os.WriteFile(filepath.Join(cfg.WorkingDirectory, "extra.tf"), []byte(`resource "aws_subnet" "a" {}`), 0644); err != nil {
+		t.Fatalf("Failed to write extra.tf: %v", err)
+	}
+
+	updated, err := UpdateSnapshot(cfg, "snap", BuildOptions{})
+	if err != nil {
+		t.Fatalf("UpdateSnapshot failed: %v", err)
+	}
+	if !updated.CreatedAt.Equal(built.CreatedAt) || updated.Description != "first" {
+		t.Errorf("Expected creation time and description to be kept, got %+v", updated)
+	}
+	if updated.ConfigAnalysis.TotalCount != 2 {
+		t.Errorf("Expected 2 resources after update, got %d", updated.ConfigAnalysis.TotalCount)
+	}
+	if _, err := os.Stat(storedFilePath(t, cfg, "snap", "tfconfig/extra.tf")); err != nil {
+		t.Errorf("Expected extra.tf in updated snapshot: %v", err)
+	}
+	assertNoInternalDirs(t, cfg)
+}
+
+// interruptSaves leaves behind what interrupted saves of "partial" and an
+// update of "kept" leave, owned by ownerPID and last modified at modTime.
+func interruptSaves(t *testing.T, cfg *config.Config, ownerPID int, modTime time.Time) {
+	t.Helper()
+
+	if _, err := BuildSnapshot(cfg, "kept", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+
+	// a save interrupted before the rename, with metadata already written
+	staging := filepath.Join(cfg.SnapshotDirectory, fmt.Sprintf("%s%d-partial-123", stagingPrefix, ownerPID))
+	if err := os.Mkdir(staging, 0755); err != nil {
+		t.Fatalf("Failed to create staging directory: %v", err)
+	}
+	if err := writeMetadata(staging, &Metadata{Id: "partial"}); err != nil {
+		t.Fatalf("writeMetadata failed: %v", err)
+	}
+
+	// an update interrupted after the old snapshot was moved aside
+	trash := filepath.Join(cfg.SnapshotDirectory, fmt.Sprintf("%s%d-kept", trashPrefix, ownerPID))
+	if err := os.Rename(filepath.Join(cfg.SnapshotDirectory, "kept"), trash); err != nil {
+		t.Fatalf("Failed to move snapshot aside: %v", err)
+	}
+
+	for _, dir := range []string{staging, trash} {
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+	}
+}
+
+func stubProcessAlive(t *testing.T, alive bool) {
+	t.Helper()
+	orig := processAlive
+	processAlive = func(int) bool { return alive }
+	t.Cleanup(func() { processAlive = orig })
+}
+
+func listedIds(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+	snapshots, err := ListSnapshots(cfg)
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Id < snapshots[j].Id })
+	return snapshotIds(snapshots)
+}
+
+func countInternalDirs(t *testing.T, cfg *config.Config) int {
+	t.Helper()
+	entries, err := os.ReadDir(cfg.SnapshotDirectory)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot directory: %v", err)
+	}
+	count := 0
+	for _, e := range entries {
+		if isInternalDir(e.Name()) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestListSnapshotsLeavesIncompleteSnapshots(t *testing.T) {
+	cfg := newTestWorkspace(t)
+	stubProcessAlive(t, false)
+	interruptSaves(t, cfg, 999999, time.Now().Add(-2*minInternalDirAge))
+
+	if ids := listedIds(t, cfg); ids != "" {
+		t.Errorf("Expected incomplete snapshots not to be listed, got %v", ids)
+	}
+	if n := countInternalDirs(t, cfg); n != 2 {
+		t.Errorf("Expected listing to leave both leftover directories, found %d", n)
+	}
+}
+
+func TestSaveCollectsAbandonedSnapshots(t *testing.T) {
+	cfg := newTestWorkspace(t)
+	stubProcessAlive(t, false)
+	interruptSaves(t, cfg, 999999, time.Now().Add(-2*minInternalDirAge))
+
+	if _, err := BuildSnapshot(cfg, "next", "", BuildOptions{}); err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if ids := listedIds(t, cfg); ids != "kept,next" {
+		t.Errorf("Expected the moved-aside snapshot to be restored, got %v", ids)
+	}
+	assertNoInternalDirs(t, cfg)
+}
+
+func TestSaveKeepsSnapshotsInProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		alive   bool
+		modTime time.Time
+	}{
+		{"owner running", true, time.Now().Add(-2 * minInternalDirAge)},
+		{"recently modified", false, time.Now()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestWorkspace(t)
+			stubProcessAlive(t, tt.alive)
+			interruptSaves(t, cfg, 999999, tt.modTime)
+
+			if _, err := BuildSnapshot(cfg, "next", "", BuildOptions{}); err != nil {
+				t.Fatalf("BuildSnapshot failed: %v", err)
+			}
+			if n := countInternalDirs(t, cfg); n != 2 {
+				t.Errorf("Expected both directories to be kept, found %d", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/phergul/tfsnap/internal/crypt"
+	"github.com/phergul/tfsnap/internal/util"
+)
+
+const (
+	snapshotStateDir = "state"
+	workingStateFile = "terraform.tfstate"
+)
+
+// stateFiles are captured relative to the working directory when a snapshot
+// includes state. Only the state file itself is required.
+var stateFiles = []string{
+	workingStateFile,
+	filepath.Join(".terraform", "modules", "modules.json"),
+}
+
+type StateInfo struct {
+	Serial           uint64   `json:"serial"`
+	Lineage          string   `json:"lineage"`
+	TerraformVersion string   `json:"terraform_version,omitempty"`
+	Encrypted        bool     `json:"encrypted,omitempty"`
+	Files            []string `json:"files"`
+}
+
+type stateHeader struct {
+	Serial           uint64 `json:"serial"`
+	Lineage          string `json:"lineage"`
+	TerraformVersion string `json:"terraform_version"`
+}
+
+// captureState copies the working state into the snapshot, encrypting each
+// file when c is set.
+func captureState(workingDir, snapshotDir string, c *crypt.Cipher) (*StateInfo, error) {
+	data, err := os.ReadFile(filepath.Join(workingDir, workingStateFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no %s in working directory", workingStateFile)
+		}
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var header stateHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", workingStateFile, err)
+	}
+
+	info := &StateInfo{
+		Serial:           header.Serial,
+		Lineage:          header.Lineage,
+		TerraformVersion: header.TerraformVersion,
+		Encrypted:        c != nil,
+	}
+
+	for _, rel := range stateFiles {
+		source := filepath.Join(workingDir, rel)
+		stat, err := os.Stat(source)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		target := filepath.Join(snapshotDir, snapshotStateDir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create state directory: %w", err)
+		}
+
+		if c != nil {
+			err = c.EncryptFile(source, target)
+		} else {
+			err = util.CopyFile(source, target)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture %s: %w", rel, err)
+		}
+		if err := os.Chmod(target, stat.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("failed to capture %s: %w", rel, err)
+		}
+
+		info.Files = append(info.Files, filepath.ToSlash(rel))
+	}
+
+	return info, nil
+}
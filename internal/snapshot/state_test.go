@@ -0,0 +1,108 @@
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phergul/tfsnap/internal/crypt"
+)
+
+const testState = `{"version": 4, "terraform_version": "1.9.0", "serial": 7, "lineage": "abc-123", "resources": []}`
+
+func writeTestState(t *testing.T, dir, state string) {
+	t.Helper()
+	writeLoadTestFiles(t, dir, map[string]string{
+		workingStateFile:                  state,
+		".terraform/modules/modules.json": `{"Modules": []}`,
+	})
+}
+
+func TestSnapshotIncludeState(t *testing.T) {
+	cfg := newTestWorkspace(t)
+	writeTestState(t, cfg.WorkingDirectory, testState)
+
+	metadata, err := BuildSnapshot(cfg, "snap", "", BuildOptions{IncludeState: true})
+	if err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if metadata.State == nil || metadata.State.Serial != 7 || metadata.State.Lineage != "abc-123" {
+		t.Fatalf("Expected serial and lineage in metadata, got %+v", metadata.State)
+	}
+	if len(metadata.State.Files) != 2 || metadata.State.Encrypted {
+		t.Errorf("Expected two unencrypted state files, got %+v", metadata.State)
+	}
+
+	if err := os.Remove(filepath.Join(cfg.WorkingDirectory, workingStateFile)); err != nil {
+		t.Fatalf("Failed to remove state: %v", err)
+	}
+
+	plan, err := PlanLoad(cfg, "snap", false)
+	if err != nil {
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+	if len(plan.Added) != 1 || plan.Added[0] != workingStateFile || len(plan.Removed) != 0 {
+		t.Errorf("Expected only the state to be added, got %+v", plan)
+	}
+	if err := ApplyLoad(cfg, plan); err != nil {
+		t.Fatalf("ApplyLoad failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.WorkingDirectory, workingStateFile))
+	if err != nil || string(data) != testState {
+		t.Errorf("Expected state to be restored, got %q (%v)", data, err)
+	}
+}
+
+func TestSnapshotEncryptState(t *testing.T) {
+	t.Setenv(crypt.PassphraseEnv, "secret")
+	cfg := newTestWorkspace(t)
+	writeTestState(t, cfg.WorkingDirectory, testState)
+
+	metadata, err := BuildSnapshot(cfg, "snap", "", BuildOptions{IncludeState: true, EncryptState: true})
+	if err != nil {
+		t.Fatalf("BuildSnapshot failed: %v", err)
+	}
+	if !metadata.State.Encrypted {
+		t.Fatal("Expected state to be marked encrypted")
+	}
+
+	stored, err := os.ReadFile(storedFilePath(t, cfg, "snap", "state/"+workingStateFile))
+	if err != nil {
+		t.Fatalf("Failed to read stored state: %v", err)
+	}
+	if bytes.Contains(stored, []byte("abc-123")) {
+		t.Error("Expected stored state to be encrypted")
+	}
+
+	// updating keeps the state encrypted
+	if _, err := UpdateSnapshot(cfg, "snap", BuildOptions{}); err != nil {
+		t.Fatalf("UpdateSnapshot failed: %v", err)
+	}
+
+	writeTestState(t, cfg.WorkingDirectory, `{"serial": 8, "lineage": "abc-123"}`)
+	plan, err := PlanLoad(cfg, "snap", false)
+	if err != nil {
+		t.Fatalf("PlanLoad failed: %v", err)
+	}
+	if !plan.ReplacesState() {
+		t.Errorf("Expected the working state to be replaced, got %+v", plan)
+	}
+	if err := ApplyLoad(cfg, plan); err != nil {
+		t.Fatalf("ApplyLoad failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.WorkingDirectory, workingStateFile))
+	if err != nil || string(data) != testState {
+		t.Errorf("Expected decrypted state to be restored, got %q (%v)", data, err)
+	}
+}
+
+func TestSnapshotIncludeStateMissing(t *testing.T) {
+	cfg := newTestWorkspace(t)
+
+	if _, err := BuildSnapshot(cfg, "snap", "", BuildOptions{IncludeState: true}); err == nil {
+		t.Error("Expected an error when there is no state to capture")
+	}
+}
@@ -0,0 +1,88 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+// Templates are plain .tf files, so their tags live next to them in a single
+// file keyed by "<resource type>/<template name>".
+const tagsFile = "tags.json"
+
+func templateKey(tmpl TemplateItem) string {
+	return tmpl.ResourceType + "/" + tmpl.Name
+}
+
+func loadTags(cfg *config.Config) (map[string][]string, error) {
+	tags := make(map[string][]string)
+	data, err := os.ReadFile(filepath.Join(templatesRoot(cfg), tagsFile))
+	if os.IsNotExist(err) {
+		return tags, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read template tags: %w", err)
+	}
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("decode template tags: %w", err)
+	}
+	return tags, nil
+}
+
+func saveTags(cfg *config.Config, tags map[string][]string) error {
+	root := templatesRoot(cfg)
+	if err := ensureDir(root); err != nil {
+		return fmt.Errorf("ensure template dir: %w", err)
+	}
+	data, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode template tags: %w", err)
+	}
+	tmp := filepath.Join(root, "."+tagsFile)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write template tags: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(root, tagsFile))
+}
+
+// TagTemplates adds tags to each template.
+func TagTemplates(cfg *config.Config, templates []TemplateItem, add []string) error {
+	tags, err := loadTags(cfg)
+	if err != nil {
+		return err
+	}
+	for _, tmpl := range templates {
+		key := templateKey(tmpl)
+		for _, tag := range add {
+			if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(tags[key], tag) {
+				tags[key] = append(tags[key], tag)
+			}
+		}
+		sort.Strings(tags[key])
+	}
+	return saveTags(cfg, tags)
+}
+
+// forgetTags drops the tags of deleted templates.
+func forgetTags(cfg *config.Config, templates []TemplateItem) error {
+	tags, err := loadTags(cfg)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, tmpl := range templates {
+		if _, ok := tags[templateKey(tmpl)]; ok {
+			delete(tags, templateKey(tmpl))
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return saveTags(cfg, tags)
+}
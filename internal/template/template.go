@@ -5,10 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/phergul/tfsnap/internal/config"
+	"github.com/phergul/tfsnap/internal/lock"
 	"github.com/phergul/tfsnap/internal/tui"
 )
 
@@ -92,6 +94,7 @@ func RunSave(cfg *config.Config, templateName string) error {
 		items[i] = tui.Item{
 			Label:   fmt.Sprintf("%s.%s", r.Type, r.Name),
 			Content: content,
+			HCL:     true,
 			Meta:    r,
 		}
 	}
@@ -137,18 +140,36 @@ func Run(cfg *config.Config) error {
 		return nil
 	}
 
+	tags, err := loadTags(cfg)
+	if err != nil {
+		return err
+	}
+
 	items := make([]tui.Item, len(templates))
 	for i, tmpl := range templates {
+		tmpl.Tags = tags[templateKey(tmpl)]
+		label := fmt.Sprintf("%s / %s", tmpl.ResourceType, tmpl.Name)
+		if len(tmpl.Tags) > 0 {
+			label += fmt.Sprintf(" [%s]", strings.Join(tmpl.Tags, ", "))
+		}
 		items[i] = tui.Item{
-			Label:   fmt.Sprintf("%s / %s", tmpl.ResourceType, tmpl.Name),
-			Content: tmpl.Content,
-			Meta:    tmpl,
+			Label:    label,
+			Content:  tmpl.Content,
+			HCL:      true,
+			Meta:     tmpl,
+			Created:  tmpl.ModTime,
+			Modified: tmpl.ModTime,
 		}
 	}
 
 	actions := []tui.Action{
 		{Key: "enter", Label: "inject", Description: "Inject template into main.tf"},
-		{Key: "d", Label: "delete", Description: "Delete this template"},
+		{Key: "d", Label: "delete", Description: "Delete the selected templates", Multi: true, Confirm: true},
+		{
+			Key: "x", Label: "export", Description: "Export the selected templates", Multi: true, Prompt: "Export to",
+			Default: func(*tui.Item) string { return "tfsnap-templates" },
+		},
+		{Key: "t", Label: "tag", Description: "Tag the selected templates", Multi: true, Prompt: "Tags (comma separated)"},
 	}
 
 	result, err := tui.RunActionSelector("Templates", items, actions)
@@ -160,26 +181,79 @@ func Run(cfg *config.Config) error {
 		return nil
 	}
 
-	tmpl, ok := result.Item.Meta.(TemplateItem)
-	if !ok {
-		return fmt.Errorf("invalid template selected")
+	var selected []TemplateItem
+	for _, item := range result.Items {
+		tmpl, ok := item.Meta.(TemplateItem)
+		if !ok {
+			return fmt.Errorf("invalid template selected")
+		}
+		selected = append(selected, tmpl)
 	}
+	tmpl := selected[0]
 
-	switch result.Action {
-	case "enter":
-		if err := injectTemplate(cfg, tmpl); err != nil {
-			return fmt.Errorf("failed to inject template: %w", err)
-		}
-		fmt.Printf("✔ Template '%s' injected successfully!\n", tmpl.Name)
+	return lock.Exclusively(func() error {
+		switch result.Action {
+		case "enter":
+			if err := injectTemplate(cfg, tmpl); err != nil {
+				return fmt.Errorf("failed to inject template: %w", err)
+			}
+			fmt.Printf("✔ Template '%s' injected successfully!\n", tmpl.Name)
+
+		case "d":
+			for _, t := range selected {
+				if err := os.Remove(t.Path); err != nil {
+					return fmt.Errorf("failed to remove template: %w", err)
+				}
+				fmt.Printf("✔ Template '%s' deleted successfully!\n", t.Name)
+			}
+			if err := forgetTags(cfg, selected); err != nil {
+				return err
+			}
 
-	case "d":
-		if err := os.Remove(tmpl.Path); err != nil {
-			return fmt.Errorf("failed to remove template: %w", err)
+		case "x":
+			if result.Input == "" {
+				fmt.Println("No export directory given.")
+				return nil
+			}
+			for _, t := range selected {
+				path, err := ExportTemplate(t, result.Input)
+				if err != nil {
+					return fmt.Errorf("failed to export template %s: %w", t.Name, err)
+				}
+				fmt.Printf("✔ Template '%s' exported to %s\n", t.Name, path)
+			}
+
+		case "t":
+			tags := tui.SplitList(result.Input)
+			if len(tags) == 0 {
+				fmt.Println("No tags given.")
+				return nil
+			}
+			if err := TagTemplates(cfg, selected, tags); err != nil {
+				return fmt.Errorf("failed to tag templates: %w", err)
+			}
+			fmt.Printf("✔ Tagged %d template(s): %s\n", len(selected), strings.Join(tags, ", "))
 		}
-		fmt.Printf("✔ Template '%s' deleted successfully!\n", tmpl.Name)
-	}
 
-	return nil
+		return nil
+	})
+}
+
+// ExportTemplate copies a template to destDir/<resource type>/<name>.tf and
+// returns the path written.
+func ExportTemplate(tmpl TemplateItem, destDir string) (string, error) {
+	dir := filepath.Join(destDir, tmpl.ResourceType)
+	if err := ensureDir(dir); err != nil {
+		return "", fmt.Errorf("ensure export dir: %w", err)
+	}
+	outPath := filepath.Join(dir, tmpl.Name+".tf")
+	if _, err := os.Stat(outPath); err == nil {
+		return "", fmt.Errorf("export target already exists: %s", outPath)
+	}
+	if err := os.WriteFile(outPath, []byte(tmpl.Content), 0o644); err != nil {
+		return "", fmt.Errorf("write template: %w", err)
+	}
+	return outPath, nil
 }
 
 func RunList(cfg *config.Config) error {
@@ -207,6 +281,13 @@ func RunRemove(cfg *config.Config, templateName string) error {
 	if err := os.Remove(templatePath); err != nil {
 		return fmt.Errorf("failed to remove template: %w", err)
 	}
+	removed := TemplateItem{
+		ResourceType: filepath.Base(filepath.Dir(templatePath)),
+		Name:         templateName,
+	}
+	if err := forgetTags(cfg, []TemplateItem{removed}); err != nil {
+		return err
+	}
 
 	fmt.Printf("✔ Template '%s' removed successfully.\n", templateName)
 	return nil
@@ -302,6 +383,8 @@ type TemplateItem struct {
 	Name         string
 	Path         string
 	Content      string
+	ModTime      time.Time
+	Tags         []string
 }
 
 func loadAllTemplates(templatesDir string) ([]TemplateItem, error) {
@@ -332,12 +415,17 @@ func loadAllTemplates(templatesDir string) ([]TemplateItem, error) {
 				if err != nil {
 					continue
 				}
+				info, err := f.Info()
+				if err != nil {
+					continue
+				}
 
 				templates = append(templates, TemplateItem{
 					ResourceType: resourceType,
 					Name:         strings.TrimSuffix(f.Name(), ".tf"),
 					Path:         templatePath,
 					Content:      string(content),
+					ModTime:      info.ModTime(),
 				})
 			}
 		}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore reports whether every rune of pattern appears in s in order,
+// ignoring case, and scores the match. Runs of consecutive runes and matches
+// at the start of a word score higher.
+func fuzzyScore(pattern, s string) (int, bool) {
+	pattern = strings.ToLower(pattern)
+	if pattern == "" {
+		return 0, true
+	}
+
+	p := []rune(pattern)
+	score, pi := 0, 0
+	prevMatched := false
+	prev := rune(0)
+	for i, r := range strings.ToLower(s) {
+		if pi == len(p) {
+			break
+		}
+		if r != p[pi] {
+			prevMatched = false
+			prev = r
+			continue
+		}
+
+		score++
+		if prevMatched {
+			score += 5
+		}
+		if i == 0 || !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+			score += 3
+		}
+		pi++
+		prevMatched = true
+		prev = r
+	}
+	return score, pi == len(p)
+}
+
+// matchItem scores an item against a filter. Label matches always rank above
+// content matches; content is matched line by line so a short pattern does
+// not match scattered letters across a whole document.
+func matchItem(filter string, item Item) (int, bool) {
+	if score, ok := fuzzyScore(filter, item.Label); ok {
+		return score + 1000, true
+	}
+
+	best, matched := 0, false
+	for _, line := range strings.Split(item.Content, "\n") {
+		if score, ok := fuzzyScore(filter, line); ok && (!matched || score > best) {
+			best, matched = score, true
+		}
+	}
+	return best, matched
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/muesli/termenv"
+)
+
+var (
+	hclCommentStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
+	hclStringStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	hclLiteralStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("215"))
+	hclBlockStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	hclAttributeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("117"))
+	hclTemplateStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("204"))
+)
+
+// colorEnabled reports whether the terminal shows colour. lipgloss detects
+// the terminal's capabilities and honours NO_COLOR and CLICOLOR.
+func colorEnabled() bool {
+	return lipgloss.ColorProfile() != termenv.Ascii
+}
+
+// HighlightHCL colours HCL source by its tokens. Whitespace is copied from
+// src unchanged, so the result has the same lines as the input. Without colour
+// support src is returned as is.
+func HighlightHCL(src string) string {
+	if !colorEnabled() {
+		return src
+	}
+	tokens, _ := hclsyntax.LexConfig([]byte(src), "", hcl.InitialPos)
+
+	var out strings.Builder
+	pos := 0
+	for i, tok := range tokens {
+		start, end := tok.Range.Start.Byte, tok.Range.End.Byte
+		if start < pos || end > len(src) {
+			continue
+		}
+		out.WriteString(src[pos:start])
+		if style, ok := tokenStyle(tokens, i); ok {
+			writeStyled(&out, style, src[start:end])
+		} else {
+			out.WriteString(src[start:end])
+		}
+		pos = end
+	}
+	out.WriteString(src[pos:])
+	return out.String()
+}
+
+func tokenStyle(tokens hclsyntax.Tokens, i int) (lipgloss.Style, bool) {
+	switch tokens[i].Type {
+	case hclsyntax.TokenComment:
+		return hclCommentStyle, true
+	case hclsyntax.TokenOQuote, hclsyntax.TokenCQuote, hclsyntax.TokenQuotedLit,
+		hclsyntax.TokenStringLit, hclsyntax.TokenOHeredoc, hclsyntax.TokenCHeredoc:
+		return hclStringStyle, true
+	case hclsyntax.TokenNumberLit:
+		return hclLiteralStyle, true
+	case hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl, hclsyntax.TokenTemplateSeqEnd:
+		return hclTemplateStyle, true
+	case hclsyntax.TokenIdent:
+		switch string(tokens[i].Bytes) {
+		case "true", "false", "null":
+			return hclLiteralStyle, true
+		}
+		if i+1 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenEqual {
+			return hclAttributeStyle, true
+		}
+		if i == 0 || startsLine(tokens[i-1].Type) {
+			return hclBlockStyle, true
+		}
+	}
+	return lipgloss.Style{}, false
+}
+
+func startsLine(prev hclsyntax.TokenType) bool {
+	return prev == hclsyntax.TokenNewline || prev == hclsyntax.TokenOBrace || prev == hclsyntax.TokenCBrace || prev == hclsyntax.TokenComment
+}
+
+// writeStyled renders each line of text separately so styling never spans a
+// line break.
+func writeStyled(out *strings.Builder, style lipgloss.Style, text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		if line != "" {
+			out.WriteString(style.Render(line))
+		}
+	}
+}
+
+var (
+	diffAddStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	diffRemoveStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	diffHunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("117"))
+	diffFileStyle   = lipgloss.NewStyle().Bold(true)
+)
+
+// HighlightDiff colours the lines of a unified diff.
+func HighlightDiff(diff string) string {
+	if !colorEnabled() {
+		return diff
+	}
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = diffFileStyle.Render(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = diffHunkStyle.Render(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = diffAddStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = diffRemoveStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestHighlightHCL(t *testing.T) {
+	src := `# network
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+  count      = 2
+  enabled    = true
+  name       = "${var.prefix}-vpc"
+}
+`
+	if got := HighlightHCL(src); got != src {
+		t.Errorf("Expected plain output without colour support, got:\n%s", got)
+	}
+
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer lipgloss.SetColorProfile(termenv.Ascii)
+
+	got := HighlightHCL(src)
+	if got == src {
+		t.Fatal("Expected highlighted output")
+	}
+	for _, want := range []string{hclBlockStyle.Render("resource"), hclAttributeStyle.Render("cidr_block"), hclLiteralStyle.Render("true"), hclCommentStyle.Render("# network")} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in highlighted output", want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package tui
+
+import "strings"
+
+// SplitList splits comma separated input, dropping empty entries.
+func SplitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -2,7 +2,9 @@ package tui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -11,18 +13,93 @@ import (
 type Item struct {
 	Label   string
 	Content string
-	Meta    any
+	// HCL marks Content as HCL source to be syntax highlighted
+	HCL  bool
+	Meta any
+	// Created and Modified are used by the date sort orders; items without
+	// them fall back to sorting by label.
+	Created  time.Time
+	Modified time.Time
+	// Previews returns further panes that tab cycles through after Content,
+	// such as the files of a snapshot. It is called when first needed.
+	Previews func() ([]Preview, error)
+}
+
+// Preview is one pane of an item's preview.
+type Preview struct {
+	Title   string
+	Content string
+	HCL     bool
 }
 
 type Action struct {
 	Key         string
 	Label       string
 	Description string
+	// Multi actions apply to every selected item; others refuse to run
+	// while more than one item is selected.
+	Multi bool
+	// Confirm asks before running the action even on a single item, for
+	// destructive actions. Multi-item actions are always confirmed.
+	Confirm bool
+	// Details describes what the action will do, shown when asking for
+	// confirmation. An error is reported instead and cancels the action.
+	Details func(items []*Item, input string) (string, error)
+	// Prompt asks for a line of input first, prefilled by Default from the
+	// first target item.
+	Prompt  string
+	Default func(item *Item) string
+	// Run handles the action inside the selector, which stays open
+	// afterwards. Actions without Run end the selector with an ActionResult.
+	Run func(items []*Item, input string) Outcome
 }
 
+// ActionResult is the action chosen and the items it applies to. Item is the
+// first of Items.
 type ActionResult struct {
 	Item   *Item
+	Items  []*Item
 	Action string
+	Input  string
+}
+
+// Outcome is what an action run inside the selector reports back.
+type Outcome struct {
+	Status string
+	Err    error
+	// Items replaces the listed items when set, e.g. after a rename
+	Items []Item
+	// Page opens a full-screen view, e.g. a diff
+	Page *Page
+}
+
+// Page is scrollable text shown over the selector until dismissed.
+type Page struct {
+	Title   string
+	Content string
+}
+
+// pendingAction is an action waiting for input or confirmation.
+type pendingAction struct {
+	action  Action
+	items   []*Item
+	input   string
+	editing bool
+	details string
+}
+
+type SortOrder int
+
+const (
+	SortByName SortOrder = iota
+	SortByCreated
+	SortByModified
+)
+
+var sortOrderNames = []string{"name", "created", "modified"}
+
+func (s SortOrder) String() string {
+	return sortOrderNames[s]
 }
 
 type SelectorModel struct {
@@ -35,76 +112,328 @@ type SelectorModel struct {
 	Quitting      bool
 	Selected      *Item
 	Result        *ActionResult
+	Sort          SortOrder
+
+	filter    string
+	filtering bool
+	// selected marks items (by index into Items) for multi-item actions
+	selected map[int]bool
+	pending  *pendingAction
+	status   string
+	failed   bool
+	page     *Page
+	// pageScroll is the first line of page shown
+	pageScroll int
+	// pane is the preview pane shown: 0 for Content, then each of previews
+	pane     int
+	previews map[int][]Preview
+	// visible holds indexes into Items in display order
+	visible []int
+	cursor  int
+	offset  int
+	scroll  int
 }
 
 func NewSelector(title string, items []Item) SelectorModel {
-	return SelectorModel{
+	m := SelectorModel{
 		Title:         title,
 		Items:         items,
 		SelectedIndex: 0,
 		Width:         120,
 		Height:        30,
+		selected:      make(map[int]bool),
+		previews:      make(map[int][]Preview),
 	}
+	m.refresh()
+	return m
 }
 
 func NewActionSelector(title string, items []Item, actions []Action) SelectorModel {
-	return SelectorModel{
-		Title:         title,
-		Items:         items,
-		Actions:       actions,
-		SelectedIndex: 0,
-		Width:         120,
-		Height:        30,
-	}
+	m := NewSelector(title, items)
+	m.Actions = actions
+	return m
 }
 
 func (m SelectorModel) Init() tea.Cmd {
 	return nil
 }
 
+// refresh recomputes the visible items after the filter or sort order
+// changed, keeping the selected item under the cursor when it is still shown.
+func (m *SelectorModel) refresh() {
+	current := -1
+	if m.cursor < len(m.visible) {
+		current = m.visible[m.cursor]
+	}
+
+	scores := make(map[int]int)
+	m.visible = make([]int, 0, len(m.Items))
+	for i, item := range m.Items {
+		if m.filter == "" {
+			m.visible = append(m.visible, i)
+			continue
+		}
+		if score, ok := matchItem(m.filter, item); ok {
+			scores[i] = score
+			m.visible = append(m.visible, i)
+		}
+	}
+
+	sort.SliceStable(m.visible, func(a, b int) bool {
+		i, j := m.visible[a], m.visible[b]
+		if scores[i] != scores[j] {
+			return scores[i] > scores[j]
+		}
+		return m.less(i, j)
+	})
+
+	m.cursor = 0
+	for pos, i := range m.visible {
+		if i == current {
+			m.cursor = pos
+			break
+		}
+	}
+	if len(m.visible) == 0 || m.visible[m.cursor] != current {
+		m.scroll = 0
+		m.pane = 0
+	}
+	m.moveTo(m.cursor)
+}
+
+// less orders items by the current sort order; dates sort newest first.
+func (m *SelectorModel) less(i, j int) bool {
+	a, b := m.Items[i], m.Items[j]
+	switch m.Sort {
+	case SortByCreated:
+		if !a.Created.Equal(b.Created) {
+			return a.Created.After(b.Created)
+		}
+	case SortByModified:
+		if !a.Modified.Equal(b.Modified) {
+			return a.Modified.After(b.Modified)
+		}
+	}
+	return a.Label < b.Label
+}
+
+func (m *SelectorModel) moveTo(pos int) {
+	pos = max(0, min(pos, len(m.visible)-1))
+	if pos != m.cursor {
+		m.scroll = 0
+		m.pane = 0
+	}
+	m.cursor = pos
+
+	m.SelectedIndex = 0
+	if len(m.visible) > 0 {
+		m.SelectedIndex = m.visible[pos]
+	}
+
+	rows := m.listRows()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.visible)-rows))
+}
+
+func (m SelectorModel) current() *Item {
+	if len(m.visible) == 0 {
+		return nil
+	}
+	return &m.Items[m.visible[m.cursor]]
+}
+
+func (m SelectorModel) contentHeight() int {
+	if len(m.Actions) > 0 {
+		return m.Height - 8
+	}
+	return m.Height - 6
+}
+
+// listRows is the number of items shown in the left pane below the title
+// and the filter line.
+func (m SelectorModel) listRows() int {
+	return max(1, m.contentHeight()-4)
+}
+
+// contentRows is the number of content lines shown in the right pane; one
+// row is kept for the scroll position and one for the pane tabs of items
+// with previews.
+func (m SelectorModel) contentRows() int {
+	if item := m.current(); item != nil && item.Previews != nil {
+		return max(1, m.contentHeight()-2)
+	}
+	return max(1, m.contentHeight()-1)
+}
+
+func (m SelectorModel) contentWidth() int {
+	return m.Width - (m.Width/3 - 2) - 10
+}
+
+func (m *SelectorModel) scrollContent(delta int) {
+	lines := len(m.contentLines())
+	m.scroll = max(0, min(m.scroll+delta, lines-m.contentRows()))
+}
+
+// currentPreview is the preview pane shown for the item under the cursor.
+func (m SelectorModel) currentPreview() Preview {
+	item := m.current()
+	if item == nil {
+		return Preview{}
+	}
+	if previews := m.previews[m.visible[m.cursor]]; m.pane > 0 && m.pane <= len(previews) {
+		return previews[m.pane-1]
+	}
+	return Preview{Title: "details", Content: item.Content, HCL: item.HCL}
+}
+
+// contentLines is the current preview split into display lines. Prose is
+// wrapped; HCL is highlighted and cut to width instead, since wrapping code
+// would misplace its colours.
+func (m SelectorModel) contentLines() []string {
+	preview := m.currentPreview()
+	if !preview.HCL {
+		return wrapContent(preview.Content, m.contentWidth())
+	}
+
+	lineStyle := lipgloss.NewStyle().MaxWidth(max(10, m.contentWidth()))
+	lines := strings.Split(strings.TrimRight(HighlightHCL(preview.Content), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = lineStyle.Render(line)
+	}
+	return lines
+}
+
+// switchPane cycles the preview panes of the item under the cursor, loading
+// its previews the first time.
+func (m *SelectorModel) switchPane(delta int) {
+	item := m.current()
+	if item == nil || item.Previews == nil {
+		return
+	}
+	i := m.visible[m.cursor]
+	if _, ok := m.previews[i]; !ok {
+		previews, err := item.Previews()
+		if err != nil {
+			m.status, m.failed = "Error: "+err.Error(), true
+			return
+		}
+		m.previews[i] = previews
+	}
+	panes := len(m.previews[i]) + 1
+	m.pane = (m.pane + delta + panes) % panes
+	m.scroll = 0
+}
+
 func (m SelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.page != nil {
+			return m.updatePage(msg)
+		}
+		if m.pending != nil {
+			return m.updatePending(msg)
+		}
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+
+		m.status, m.failed = "", false
 		switch msg.String() {
-		case "ctrl+c", "q", "esc":
+		case "ctrl+c", "q":
+			m.Quitting = true
+			return m, tea.Quit
+
+		case "esc":
+			if m.filter != "" {
+				m.filter = ""
+				m.refresh()
+				return m, nil
+			}
+			if len(m.selected) > 0 {
+				m.selected = make(map[int]bool)
+				return m, nil
+			}
 			m.Quitting = true
 			return m, tea.Quit
 
+		case "/":
+			m.filtering = true
+
 		case "enter":
-			if len(m.Items) > 0 {
-				if len(m.Actions) > 0 {
-					m.Result = &ActionResult{
-						Item:   &m.Items[m.SelectedIndex],
-						Action: "enter",
-					}
-				} else {
-					m.Selected = &m.Items[m.SelectedIndex]
-				}
+			if item := m.current(); item != nil && len(m.Actions) == 0 {
+				m.Selected = item
+			} else if item != nil {
+				return m.runAction(Action{Key: "enter", Label: m.actionLabel("enter")})
 			}
 			m.Quitting = true
 			return m, tea.Quit
 
-		case "up", "k":
-			if m.SelectedIndex > 0 {
-				m.SelectedIndex--
+		case " ":
+			if m.multiSelect() && len(m.visible) > 0 {
+				i := m.visible[m.cursor]
+				if m.selected[i] {
+					delete(m.selected, i)
+				} else {
+					m.selected[i] = true
+				}
+				m.moveTo(m.cursor + 1)
 			}
 
-		case "down", "j":
-			if m.SelectedIndex < len(m.Items)-1 {
-				m.SelectedIndex++
+		case "a":
+			if m.multiSelect() {
+				m.toggleAll()
 			}
 
+		case "up", "k":
+			m.moveTo(m.cursor - 1)
+
+		case "down", "j":
+			m.moveTo(m.cursor + 1)
+
+		case "pgup", "ctrl+b":
+			m.moveTo(m.cursor - m.listRows())
+
+		case "pgdown", "ctrl+f":
+			m.moveTo(m.cursor + m.listRows())
+
+		case "home", "g":
+			m.moveTo(0)
+
+		case "end", "G":
+			m.moveTo(len(m.visible) - 1)
+
+		case "s":
+			m.Sort = (m.Sort + 1) % SortOrder(len(sortOrderNames))
+			m.refresh()
+
+		case "K":
+			m.scrollContent(-1)
+
+		case "J":
+			m.scrollContent(1)
+
+		case "ctrl+u":
+			m.scrollContent(-m.contentRows() / 2)
+
+		case "ctrl+d":
+			m.scrollContent(m.contentRows() / 2)
+
+		case "tab":
+			m.switchPane(1)
+
+		case "shift+tab":
+			m.switchPane(-1)
+
 		default:
-			if len(m.Actions) > 0 && len(m.Items) > 0 {
+			if m.current() != nil {
 				keyStr := msg.String()
 				for _, action := range m.Actions {
 					if keyStr == action.Key {
-						m.Result = &ActionResult{
-							Item:   &m.Items[m.SelectedIndex],
-							Action: action.Key,
-						}
-						m.Quitting = true
-						return m, tea.Quit
+						return m.runAction(action)
 					}
 				}
 			}
@@ -113,8 +442,266 @@ func (m SelectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
+		m.moveTo(m.cursor)
+		m.scrollContent(0)
+		m.scrollPage(0)
+	}
+
+	return m, nil
+}
+
+func (m SelectorModel) multiSelect() bool {
+	for _, action := range m.Actions {
+		if action.Multi {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleAll selects every visible item, or clears them if all already are.
+func (m *SelectorModel) toggleAll() {
+	all := true
+	for _, i := range m.visible {
+		all = all && m.selected[i]
+	}
+	for _, i := range m.visible {
+		if all {
+			delete(m.selected, i)
+		} else {
+			m.selected[i] = true
+		}
+	}
+}
+
+func (m SelectorModel) actionLabel(key string) string {
+	for _, action := range m.Actions {
+		if action.Key == key {
+			return action.Label
+		}
+	}
+	return key
+}
+
+func (m SelectorModel) findAction(key string) (Action, bool) {
+	for _, action := range m.Actions {
+		if action.Key == key {
+			return action, true
+		}
+	}
+	return Action{}, false
+}
+
+// targets returns the selected items, including any hidden by the filter,
+// or the item under the cursor when nothing is selected.
+func (m SelectorModel) targets() []*Item {
+	var items []*Item
+	for i := range m.Items {
+		if m.selected[i] {
+			items = append(items, &m.Items[i])
+		}
+	}
+	if len(items) == 0 {
+		if item := m.current(); item != nil {
+			items = append(items, item)
+		}
 	}
+	return items
+}
+
+// runAction starts action on the target items, asking for its input and
+// confirmation first when needed.
+func (m SelectorModel) runAction(action Action) (tea.Model, tea.Cmd) {
+	if a, ok := m.findAction(action.Key); ok {
+		action = a
+	}
+
+	items := m.targets()
+	if len(items) > 1 && !action.Multi {
+		m.status = fmt.Sprintf("%s works on a single item; press esc to clear the selection", action.Label)
+		return m, nil
+	}
+
+	p := &pendingAction{action: action, items: items}
+	if action.Prompt != "" {
+		p.editing = true
+		if action.Default != nil {
+			p.input = action.Default(items[0])
+		}
+		m.pending = p
+		return m, nil
+	}
+	return m.confirmOrRun(p)
+}
 
+func (m SelectorModel) confirmOrRun(p *pendingAction) (tea.Model, tea.Cmd) {
+	if p.action.Confirm || len(p.items) > 1 {
+		if p.action.Details != nil {
+			details, err := p.action.Details(p.items, p.input)
+			if err != nil {
+				m.pending = nil
+				m.status, m.failed = "Error: "+err.Error(), true
+				return m, nil
+			}
+			p.details = details
+		}
+		m.pending = p
+		return m, nil
+	}
+	return m.execute(p)
+}
+
+// execute runs an action in place, or ends the selector with its result when
+// the caller handles it.
+func (m SelectorModel) execute(p *pendingAction) (tea.Model, tea.Cmd) {
+	m.pending = nil
+	if p.action.Run == nil {
+		m.Result = &ActionResult{Item: p.items[0], Items: p.items, Action: p.action.Key, Input: p.input}
+		m.Quitting = true
+		return m, tea.Quit
+	}
+
+	outcome := p.action.Run(p.items, p.input)
+	m.status, m.failed = outcome.Status, false
+	if outcome.Err != nil {
+		m.status, m.failed = "Error: "+outcome.Err.Error(), true
+	}
+	if outcome.Items != nil {
+		m.replaceItems(outcome.Items)
+	}
+	if outcome.Page != nil {
+		m.page, m.pageScroll = outcome.Page, 0
+	}
+	return m, nil
+}
+
+// replaceItems swaps in a new item list, keeping the cursor on the item with
+// the same label when there still is one. The selection is cleared.
+func (m *SelectorModel) replaceItems(items []Item) {
+	label := ""
+	if item := m.current(); item != nil {
+		label = item.Label
+	}
+	m.Items = items
+	m.selected = make(map[int]bool)
+	m.previews = make(map[int][]Preview)
+	m.visible = nil
+	m.refresh()
+	for pos, i := range m.visible {
+		if m.Items[i].Label == label {
+			m.moveTo(pos)
+			break
+		}
+	}
+}
+
+// updatePending edits the input of a pending action, then asks for
+// confirmation where needed.
+func (m SelectorModel) updatePending(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := *m.pending
+	if msg.Type == tea.KeyCtrlC {
+		m.Quitting = true
+		return m, tea.Quit
+	}
+
+	if p.editing {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.pending = nil
+			m.status = "cancelled"
+		case tea.KeyEnter:
+			p.editing = false
+			return m.confirmOrRun(&p)
+		case tea.KeyBackspace:
+			if r := []rune(p.input); len(r) > 0 {
+				p.input = string(r[:len(r)-1])
+			}
+			m.pending = &p
+		case tea.KeyCtrlU:
+			p.input = ""
+			m.pending = &p
+		case tea.KeyRunes, tea.KeySpace:
+			p.input += string(msg.Runes)
+			m.pending = &p
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "y", "Y":
+		return m.execute(&p)
+	}
+	m.pending = nil
+	m.status = "cancelled"
+	return m, nil
+}
+
+func (m SelectorModel) pageRows() int {
+	return max(1, m.Height-4)
+}
+
+func (m *SelectorModel) scrollPage(delta int) {
+	if m.page == nil {
+		return
+	}
+	lines := strings.Count(m.page.Content, "\n") + 1
+	m.pageScroll = max(0, min(m.pageScroll+delta, lines-m.pageRows()))
+}
+
+func (m SelectorModel) updatePage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.Quitting = true
+		return m, tea.Quit
+	case "q", "esc", "enter":
+		m.page = nil
+	case "up", "k":
+		m.scrollPage(-1)
+	case "down", "j":
+		m.scrollPage(1)
+	case "pgup", "ctrl+b", "ctrl+u":
+		m.scrollPage(-m.pageRows())
+	case "pgdown", "ctrl+f", "ctrl+d", " ":
+		m.scrollPage(m.pageRows())
+	case "home", "g":
+		m.pageScroll = 0
+	case "end", "G":
+		m.scrollPage(strings.Count(m.page.Content, "\n") + 1)
+	}
+	return m, nil
+}
+
+// updateFilter edits the filter while `/` search is active. Enter keeps the
+// filter and returns to navigation; esc clears it.
+func (m SelectorModel) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.Quitting = true
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.filtering = false
+		m.filter = ""
+	case tea.KeyEnter:
+		m.filtering = false
+		return m, nil
+	case tea.KeyUp:
+		m.moveTo(m.cursor - 1)
+		return m, nil
+	case tea.KeyDown:
+		m.moveTo(m.cursor + 1)
+		return m, nil
+	case tea.KeyBackspace:
+		if r := []rune(m.filter); len(r) > 0 {
+			m.filter = string(r[:len(r)-1])
+		}
+	case tea.KeyCtrlU:
+		m.filter = ""
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+	default:
+		return m, nil
+	}
+	m.refresh()
 	return m, nil
 }
 
@@ -123,10 +710,21 @@ func (m SelectorModel) View() string {
 		return ""
 	}
 
+	if m.page != nil {
+		return m.viewPage()
+	}
+	if m.pending != nil {
+		return m.viewModal()
+	}
+
 	if len(m.Items) == 0 {
-		return lipgloss.NewStyle().
+		view := lipgloss.NewStyle().
 			Padding(1, 2).
 			Render("No items available.")
+		if m.status != "" {
+			view += "\n" + lipgloss.NewStyle().Padding(0, 2).Render(m.status)
+		}
+		return view
 	}
 
 	titleStyle := lipgloss.NewStyle().
@@ -142,47 +740,67 @@ func (m SelectorModel) View() string {
 	normalStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("252"))
 
+	dimStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241"))
+
+	markStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("86")).
+		Bold(true)
+
 	helpStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("241")).
 		Padding(1, 0, 0, 2)
 
 	leftWidth := m.Width/3 - 2
 	rightWidth := m.Width - leftWidth - 6
-	contentHeight := m.Height - 6
-	if len(m.Actions) > 0 {
-		contentHeight = m.Height - 8
-	}
+	contentHeight := m.contentHeight()
 
 	var leftPane strings.Builder
-	leftPane.WriteString(titleStyle.Render(m.Title) + "\n\n")
-
-	visibleStart := 0
-	visibleEnd := len(m.Items)
+	position := 0
+	if len(m.visible) > 0 {
+		position = m.cursor + 1
+	}
+	counter := fmt.Sprintf(" %d/%d", position, len(m.visible))
+	if len(m.selected) > 0 {
+		counter += fmt.Sprintf(" • %d selected", len(m.selected))
+	}
+	leftPane.WriteString(titleStyle.Render(m.Title) + dimStyle.Render(counter) + "\n")
 
-	maxVisible := contentHeight - 2
-	if len(m.Items) > maxVisible {
-		if m.SelectedIndex > maxVisible/2 {
-			visibleStart = min(m.SelectedIndex-maxVisible/2, len(m.Items)-maxVisible)
-			visibleEnd = min(visibleStart+maxVisible, len(m.Items))
-		} else {
-			visibleEnd = min(maxVisible, len(m.Items))
-		}
+	switch {
+	case m.filtering:
+		leftPane.WriteString(normalStyle.Render("/"+m.filter) + selectedStyle.Render(" ") + "\n\n")
+	case m.filter != "":
+		leftPane.WriteString(dimStyle.Render("filter: "+m.filter+" • sort: "+m.Sort.String()) + "\n\n")
+	default:
+		leftPane.WriteString(dimStyle.Render("sort: "+m.Sort.String()) + "\n\n")
 	}
 
-	for i := visibleStart; i < visibleEnd; i++ {
+	if len(m.visible) == 0 {
+		leftPane.WriteString(dimStyle.Render("  no matches"))
+	}
+	end := min(m.offset+m.listRows(), len(m.visible))
+	for pos := m.offset; pos < end; pos++ {
 		cursor := "  "
 		style := normalStyle
-		if i == m.SelectedIndex {
+		if pos == m.cursor {
 			cursor = "▸ "
 			style = selectedStyle
 		}
 
-		label := m.Items[i].Label
-		if len(label) > leftWidth-4 {
-			label = label[:leftWidth-7] + "..."
+		mark, labelWidth := "", leftWidth-4
+		if len(m.selected) > 0 {
+			mark, labelWidth = "  ", leftWidth-6
+			if m.selected[m.visible[pos]] {
+				mark = markStyle.Render("✓ ")
+			}
+		}
+
+		label := m.Items[m.visible[pos]].Label
+		if len(label) > labelWidth {
+			label = label[:labelWidth-3] + "..."
 		}
 
-		leftPane.WriteString(cursor + style.Render(label) + "\n")
+		leftPane.WriteString(cursor + mark + style.Render(label) + "\n")
 	}
 
 	leftPaneStyle := lipgloss.NewStyle().
@@ -193,42 +811,15 @@ func (m SelectorModel) View() string {
 		Padding(0, 1)
 
 	var rightPane strings.Builder
-	if len(m.Items) > 0 {
-		content := m.Items[m.SelectedIndex].Content
-
-		lines := strings.Split(content, "\n")
-		var wrappedLines []string
-		for _, line := range lines {
-			if len(line) <= rightWidth-4 {
-				wrappedLines = append(wrappedLines, line)
-			} else {
-				words := strings.Fields(line)
-				currentLine := ""
-				for _, word := range words {
-					if len(currentLine)+len(word)+1 <= rightWidth-4 {
-						if currentLine != "" {
-							currentLine += " "
-						}
-						currentLine += word
-					} else {
-						if currentLine != "" {
-							wrappedLines = append(wrappedLines, currentLine)
-						}
-						currentLine = word
-					}
-				}
-				if currentLine != "" {
-					wrappedLines = append(wrappedLines, currentLine)
-				}
-			}
-		}
-
-		displayLines := wrappedLines
-		if len(displayLines) > contentHeight-2 {
-			displayLines = displayLines[:contentHeight-2]
-		}
-
-		rightPane.WriteString(strings.Join(displayLines, "\n"))
+	if item := m.current(); item != nil && item.Previews != nil {
+		rightPane.WriteString(m.viewTabs(selectedStyle, dimStyle) + "\n")
+	}
+	lines := m.contentLines()
+	rows := m.contentRows()
+	start := min(m.scroll, max(0, len(lines)-rows))
+	rightPane.WriteString(strings.Join(lines[start:min(start+rows, len(lines))], "\n"))
+	if len(lines) > rows {
+		rightPane.WriteString("\n" + dimStyle.Render(fmt.Sprintf("── lines %d-%d of %d (J/K to scroll) ──", start+1, min(start+rows, len(lines)), len(lines))))
 	}
 
 	rightPaneStyle := lipgloss.NewStyle().
@@ -248,13 +839,26 @@ func (m SelectorModel) View() string {
 	)
 
 	var help string
-	if len(m.Actions) > 0 {
+	switch {
+	case m.failed:
+		help = helpStyle.Foreground(lipgloss.Color("196")).Render(m.status)
+	case m.status != "":
+		help = helpStyle.Render(m.status)
+	case m.filtering:
+		help = helpStyle.Render("type to filter • ↑/↓: navigate • enter: done • esc: clear")
+	case len(m.Actions) > 0:
 		actionStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("86")).
 			Bold(true)
 
 		var helpParts []string
-		helpParts = append(helpParts, "↑/↓: navigate")
+		helpParts = append(helpParts, "↑/↓: navigate", "/: filter", "s: sort")
+		if item := m.current(); item != nil && item.Previews != nil {
+			helpParts = append(helpParts, "tab: preview")
+		}
+		if m.multiSelect() {
+			helpParts = append(helpParts, "space: select", "a: all")
+		}
 
 		for _, action := range m.Actions {
 			helpParts = append(helpParts, fmt.Sprintf("%s: %s", actionStyle.Render(action.Key), action.Label))
@@ -262,13 +866,161 @@ func (m SelectorModel) View() string {
 
 		helpParts = append(helpParts, "q: quit")
 		help = helpStyle.Render(strings.Join(helpParts, " • "))
-	} else {
-		help = helpStyle.Render("↑/↓: navigate • enter: select • q/esc: quit")
+	default:
+		help = helpStyle.Render("↑/↓: navigate • /: filter • s: sort • enter: select • q/esc: quit")
 	}
 
 	return fmt.Sprintf("%s\n%s", content, help)
 }
 
+// viewTabs names the preview panes of the item under the cursor, marking the
+// one shown. Panes not loaded yet are hinted at with tab.
+func (m SelectorModel) viewTabs(selectedStyle, dimStyle lipgloss.Style) string {
+	titles := []string{"details"}
+	previews, loaded := m.previews[m.visible[m.cursor]]
+	for _, preview := range previews {
+		titles = append(titles, preview.Title)
+	}
+
+	var tabs []string
+	for i, title := range titles {
+		if i == m.pane {
+			tabs = append(tabs, selectedStyle.Render(" "+title+" "))
+		} else {
+			tabs = append(tabs, dimStyle.Render(" "+title+" "))
+		}
+	}
+	line := strings.Join(tabs, dimStyle.Render("│"))
+	if !loaded {
+		line += dimStyle.Render(" (tab: files)")
+	}
+	return lipgloss.NewStyle().MaxWidth(max(10, m.contentWidth())).Render(line)
+}
+
+// viewPage shows the open page full screen. Lines are cut rather than wrapped
+// since they may carry colour codes.
+func (m SelectorModel) viewPage() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("170")).
+		Padding(0, 1)
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241"))
+
+	lines := strings.Split(m.page.Content, "\n")
+	rows := m.pageRows()
+	start := min(m.pageScroll, max(0, len(lines)-rows))
+	end := min(start+rows, len(lines))
+
+	lineStyle := lipgloss.NewStyle().MaxWidth(max(10, m.Width-2))
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.page.Title) + dimStyle.Render(fmt.Sprintf(" lines %d-%d of %d", start+1, end, len(lines))) + "\n\n")
+	for _, line := range lines[start:end] {
+		b.WriteString(" " + lineStyle.Render(line) + "\n")
+	}
+	b.WriteString("\n" + dimStyle.Render("  ↑/↓: scroll • pgup/pgdn: page • g/G: top/bottom • q/esc: close"))
+	return b.String()
+}
+
+// viewModal shows the input or confirmation dialog of a pending action.
+func (m SelectorModel) viewModal() string {
+	p := m.pending
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("170"))
+
+	warnStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("214"))
+
+	dimStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241"))
+
+	cursorStyle := lipgloss.NewStyle().
+		Background(lipgloss.Color("252"))
+
+	target := p.items[0].Label
+	if len(p.items) > 1 {
+		target = fmt.Sprintf("%d items", len(p.items))
+	}
+
+	var b strings.Builder
+	if p.editing {
+		b.WriteString(titleStyle.Render(fmt.Sprintf("%s %s", p.action.Label, target)) + "\n\n")
+		b.WriteString(p.action.Prompt + ": " + p.input + cursorStyle.Render(" ") + "\n\n")
+		b.WriteString(dimStyle.Render("enter: ok • esc: cancel"))
+	} else {
+		b.WriteString(warnStyle.Render(fmt.Sprintf("%s %s?", p.action.Label, target)) + "\n")
+		if len(p.items) > 1 {
+			for i, item := range p.items {
+				if i == 5 {
+					b.WriteString(dimStyle.Render(fmt.Sprintf("  …and %d more", len(p.items)-5)) + "\n")
+					break
+				}
+				b.WriteString("  " + item.Label + "\n")
+			}
+		}
+		if p.input != "" {
+			b.WriteString("\n" + p.action.Prompt + ": " + p.input + "\n")
+		}
+		if p.details != "" {
+			lines := strings.Split(strings.TrimRight(p.details, "\n"), "\n")
+			rows := max(1, m.Height-12)
+			b.WriteString("\n")
+			for i, line := range lines {
+				if i == rows && len(lines) > rows+1 {
+					b.WriteString(dimStyle.Render(fmt.Sprintf("  …and %d more", len(lines)-rows)) + "\n")
+					break
+				}
+				b.WriteString(line + "\n")
+			}
+		}
+		b.WriteString("\n" + dimStyle.Render("y: confirm • n/esc: cancel"))
+	}
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("170")).
+		Padding(1, 2).
+		Render(b.String())
+	return lipgloss.Place(m.Width, m.Height-1, lipgloss.Center, lipgloss.Center, box)
+}
+
+// wrapContent splits content into lines no wider than width, wrapping long
+// lines at word boundaries.
+func wrapContent(content string, width int) []string {
+	width = max(width, 10)
+
+	var wrappedLines []string
+	for _, line := range strings.Split(content, "\n") {
+		if len(line) <= width {
+			wrappedLines = append(wrappedLines, line)
+			continue
+		}
+
+		currentLine := ""
+		for _, word := range strings.Fields(line) {
+			if len(currentLine)+len(word)+1 <= width {
+				if currentLine != "" {
+					currentLine += " "
+				}
+				currentLine += word
+			} else {
+				if currentLine != "" {
+					wrappedLines = append(wrappedLines, currentLine)
+				}
+				currentLine = word
+			}
+		}
+		if currentLine != "" {
+			wrappedLines = append(wrappedLines, currentLine)
+		}
+	}
+	return wrappedLines
+}
+
 func RunSelector(title string, items []Item) (*Item, error) {
 	m := NewSelector(title, items)
 	p := tea.NewProgram(m)
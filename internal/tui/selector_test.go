@@ -0,0 +1,339 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func sendKeys(m SelectorModel, keys ...string) SelectorModel {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		case "pgdown":
+			msg = tea.KeyMsg{Type: tea.KeyPgDown}
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "shift+tab":
+			msg = tea.KeyMsg{Type: tea.KeyShiftTab}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		model, _ := m.Update(msg)
+		m = model.(SelectorModel)
+	}
+	return m
+}
+
+func visibleLabels(m SelectorModel) string {
+	labels := make([]string, len(m.visible))
+	for i, idx := range m.visible {
+		labels[i] = m.Items[idx].Label
+	}
+	return strings.Join(labels, ",")
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("avpc", "aws_vpc"); !ok {
+		t.Error("Expected avpc to match aws_vpc")
+	}
+	if _, ok := fuzzyScore("vpca", "aws_vpc"); ok {
+		t.Error("Expected out of order pattern not to match")
+	}
+
+	contiguous, _ := fuzzyScore("vpc", "aws_vpc")
+	scattered, _ := fuzzyScore("vpc", "very_private_cluster")
+	if contiguous <= scattered {
+		t.Errorf("Expected contiguous match to score higher: %d vs %d", contiguous, scattered)
+	}
+}
+
+func TestSelectorFilter(t *testing.T) {
+	m := NewSelector("Test", []Item{
+		{Label: "aws_subnet"},
+		{Label: "aws_vpc"},
+		{Label: "bucket", Content: "resource \"aws_s3_bucket\" \"b\" {\n  versioning = true\n}"},
+	})
+
+	m = sendKeys(m, "/", "v", "p", "c")
+	if got := visibleLabels(m); got != "aws_vpc" {
+		t.Errorf("Expected label match only, got %s", got)
+	}
+
+	m = sendKeys(m, "backspace", "backspace", "backspace", "v", "e", "r", "s")
+	if got := visibleLabels(m); got != "bucket" {
+		t.Errorf("Expected content match, got %s", got)
+	}
+
+	m = sendKeys(m, "enter")
+	if m.filtering || m.filter != "vers" {
+		t.Errorf("Expected enter to keep the filter and leave filter mode, got %q (filtering %v)", m.filter, m.filtering)
+	}
+
+	m = sendKeys(m, "esc")
+	if m.Quitting || visibleLabels(m) != "aws_subnet,aws_vpc,bucket" {
+		t.Errorf("Expected esc to clear the filter first, got %s (quitting %v)", visibleLabels(m), m.Quitting)
+	}
+}
+
+func TestSelectorSort(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	m := NewSelector("Test", []Item{
+		{Label: "b", Created: day(1), Modified: day(5)},
+		{Label: "a", Created: day(2), Modified: day(3)},
+		{Label: "c", Created: day(3), Modified: day(4)},
+	})
+
+	if got := visibleLabels(m); got != "a,b,c" {
+		t.Errorf("Expected name order, got %s", got)
+	}
+	m = sendKeys(m, "s")
+	if got := visibleLabels(m); m.Sort != SortByCreated || got != "c,a,b" {
+		t.Errorf("Expected newest created first, got %s", got)
+	}
+	m = sendKeys(m, "s")
+	if got := visibleLabels(m); m.Sort != SortByModified || got != "b,c,a" {
+		t.Errorf("Expected newest modified first, got %s", got)
+	}
+	m = sendKeys(m, "s")
+	if m.Sort != SortByName {
+		t.Errorf("Expected sort order to cycle back to name, got %s", m.Sort)
+	}
+}
+
+func TestSelectorPagingAndScrolling(t *testing.T) {
+	items := make([]Item, 100)
+	for i := range items {
+		items[i] = Item{Label: fmt.Sprintf("item-%03d", i), Content: strings.Repeat("line\n", 200)}
+	}
+	m := NewSelector("Test", items)
+
+	m = sendKeys(m, "pgdown")
+	if m.cursor != m.listRows() || m.offset == 0 {
+		t.Errorf("Expected page down to move a page and scroll the list, got cursor %d offset %d", m.cursor, m.offset)
+	}
+	m = sendKeys(m, "G")
+	if m.SelectedIndex != 99 || m.offset != 100-m.listRows() {
+		t.Errorf("Expected G to select the last item, got %d (offset %d)", m.SelectedIndex, m.offset)
+	}
+
+	m = sendKeys(m, "J", "J")
+	if m.scroll != 2 {
+		t.Errorf("Expected content to scroll two lines, got %d", m.scroll)
+	}
+	m = sendKeys(m, "k")
+	if m.scroll != 0 {
+		t.Errorf("Expected content scroll to reset on a new item, got %d", m.scroll)
+	}
+}
+
+func TestSelectorMultiSelect(t *testing.T) {
+	actions := []Action{
+		{Key: "enter", Label: "load"},
+		{Key: "d", Label: "delete", Multi: true},
+	}
+	items := []Item{{Label: "alpha"}, {Label: "beta"}, {Label: "gamma"}}
+
+	m := sendKeys(NewActionSelector("Test", items, actions), " ", " ")
+	if len(m.selected) != 2 {
+		t.Fatalf("Expected 2 selected items, got %d", len(m.selected))
+	}
+
+	m = sendKeys(m, "enter")
+	if m.Result != nil || m.status == "" {
+		t.Errorf("Expected single-item action to be refused with a selection, got %+v", m.Result)
+	}
+
+	m = sendKeys(m, "d")
+	if m.Result != nil || m.pending == nil {
+		t.Fatalf("Expected bulk delete to ask for confirmation")
+	}
+	cancelled := sendKeys(m, "n")
+	if cancelled.Result != nil || cancelled.pending != nil {
+		t.Errorf("Expected confirmation to be cancelled")
+	}
+
+	m = sendKeys(m, "y")
+	if m.Result == nil || m.Result.Action != "d" {
+		t.Fatalf("Expected delete result after confirming, got %+v", m.Result)
+	}
+	var labels []string
+	for _, item := range m.Result.Items {
+		labels = append(labels, item.Label)
+	}
+	if strings.Join(labels, ",") != "alpha,beta" {
+		t.Errorf("Expected alpha and beta, got %v", labels)
+	}
+}
+
+func TestSelectorSelectAllFiltered(t *testing.T) {
+	actions := []Action{{Key: "d", Label: "delete", Multi: true}}
+	items := []Item{{Label: "aws_vpc"}, {Label: "aws_subnet"}, {Label: "bucket"}}
+
+	m := sendKeys(NewActionSelector("Test", items, actions), "/", "a", "w", "s", "enter", "a")
+	if len(m.selected) != 2 || !m.selected[0] || !m.selected[1] {
+		t.Errorf("Expected only the filtered items to be selected, got %v", m.selected)
+	}
+
+	m = sendKeys(m, "a")
+	if len(m.selected) != 0 {
+		t.Errorf("Expected a to clear a full selection, got %v", m.selected)
+	}
+
+	// without multi actions space and a do nothing
+	m = sendKeys(NewActionSelector("Test", items, []Action{{Key: "enter", Label: "load"}}), " ", "a")
+	if len(m.selected) != 0 {
+		t.Errorf("Expected no selection without multi actions, got %v", m.selected)
+	}
+}
+
+func TestSelectorRunsActionsInPlace(t *testing.T) {
+	var renamed string
+	actions := []Action{
+		{
+			Key: "r", Label: "rename", Prompt: "New name",
+			Default: func(item *Item) string { return item.Label },
+			Run: func(items []*Item, input string) Outcome {
+				renamed = items[0].Label + "->" + input
+				return Outcome{Status: "renamed", Items: []Item{{Label: input}, {Label: "beta"}}}
+			},
+		},
+		{
+			Key: "d", Label: "delete", Confirm: true,
+			Run: func(items []*Item, _ string) Outcome {
+				return Outcome{Items: []Item{}}
+			},
+		},
+		{
+			Key: "v", Label: "view",
+			Run: func(items []*Item, _ string) Outcome {
+				return Outcome{Page: &Page{Title: items[0].Label, Content: "line 1\nline 2"}}
+			},
+		},
+	}
+	m := NewActionSelector("Test", []Item{{Label: "alpha"}, {Label: "beta"}}, actions)
+
+	m = sendKeys(m, "r")
+	if m.pending == nil || m.pending.input != "alpha" {
+		t.Fatalf("Expected rename prompt prefilled with alpha, got %+v", m.pending)
+	}
+	m = sendKeys(m, "backspace", "backspace", "backspace", "backspace", "backspace", "gamma", "enter")
+	if m.Quitting || renamed != "alpha->gamma" {
+		t.Fatalf("Expected rename to run in place, got %q (quitting %v)", renamed, m.Quitting)
+	}
+	if visibleLabels(m) != "beta,gamma" || m.current().Label != "beta" || m.status != "renamed" {
+		t.Errorf("Expected refreshed items, got %s with cursor on %s", visibleLabels(m), m.current().Label)
+	}
+
+	m = sendKeys(m, "v")
+	if m.page == nil || m.page.Title != "beta" {
+		t.Fatalf("Expected view to open a page, got %+v", m.page)
+	}
+	m = sendKeys(m, "q")
+	if m.page != nil || m.Quitting {
+		t.Errorf("Expected q to close the page only")
+	}
+
+	m = sendKeys(m, "d")
+	if m.pending == nil {
+		t.Fatalf("Expected delete to ask for confirmation")
+	}
+	m = sendKeys(m, "y")
+	if len(m.Items) != 0 || m.Quitting {
+		t.Errorf("Expected items to be replaced while staying open, got %d items", len(m.Items))
+	}
+}
+
+func TestSelectorConfirmDetails(t *testing.T) {
+	actions := []Action{
+		{
+			Key: "enter", Label: "load", Confirm: true,
+			Details: func(items []*Item, _ string) (string, error) {
+				if items[0].Label == "broken" {
+					return "", fmt.Errorf("snapshot is missing")
+				}
+				return "1 added, 0 changed, 0 removed\n+ main.tf", nil
+			},
+		},
+	}
+	m := NewActionSelector("Test", []Item{{Label: "broken"}, {Label: "snap"}}, actions)
+	m.Width, m.Height = 80, 30
+
+	m = sendKeys(m, "enter")
+	if m.pending != nil || !m.failed || !strings.Contains(m.status, "missing") {
+		t.Fatalf("Expected details error to cancel the action, got %q", m.status)
+	}
+
+	m = sendKeys(m, "j", "enter")
+	if m.pending == nil || m.Result != nil {
+		t.Fatalf("Expected load to ask for confirmation")
+	}
+	if view := m.View(); !strings.Contains(view, "+ main.tf") || !strings.Contains(view, "1 added") {
+		t.Errorf("Expected confirmation to show the details, got:\n%s", view)
+	}
+	m = sendKeys(m, "y")
+	if m.Result == nil || m.Result.Item.Label != "snap" {
+		t.Errorf("Expected confirmed load to end with a result, got %+v", m.Result)
+	}
+}
+
+func TestSelectorPreviewPanes(t *testing.T) {
+	loads := 0
+	items := []Item{
+		{
+			Label:   "snap",
+			Content: "Created: today",
+			Previews: func() ([]Preview, error) {
+				loads++
+				return []Preview{
+					{Title: "main.tf", Content: "resource \"aws_vpc\" \"main\" {}\n", HCL: true},
+					{Title: "vars.tf", Content: "variable \"region\" {}\n", HCL: true},
+				}, nil
+			},
+		},
+		{
+			Label:    "broken",
+			Previews: func() ([]Preview, error) { return nil, fmt.Errorf("snapshot is encrypted") },
+		},
+	}
+	m := NewActionSelector("Test", items, []Action{{Key: "enter", Label: "load"}})
+
+	// broken sorts first
+	m = sendKeys(m, "tab")
+	if !m.failed || !strings.Contains(m.status, "encrypted") || m.pane != 0 {
+		t.Errorf("Expected a failed preview load to be reported, got %q", m.status)
+	}
+
+	m = sendKeys(m, "j", "tab")
+	if got := m.currentPreview(); got.Title != "main.tf" || !got.HCL {
+		t.Fatalf("Expected tab to show main.tf, got %+v", got)
+	}
+	m = sendKeys(m, "tab", "tab")
+	if got := m.currentPreview(); got.Title != "details" || got.Content != "Created: today" {
+		t.Errorf("Expected tab to cycle back to the details, got %+v", got)
+	}
+	m = sendKeys(m, "shift+tab")
+	if got := m.currentPreview(); got.Title != "vars.tf" {
+		t.Errorf("Expected shift+tab to go back to vars.tf, got %+v", got)
+	}
+	if loads != 1 {
+		t.Errorf("Expected previews to load once, loaded %d times", loads)
+	}
+	if !strings.Contains(m.View(), "vars.tf") {
+		t.Error("Expected the pane tabs in the view")
+	}
+
+	m = sendKeys(m, "k")
+	if m.pane != 0 {
+		t.Errorf("Expected moving to another item to show its details")
+	}
+}
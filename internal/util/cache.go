@@ -1,11 +1,20 @@
 package util
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -13,23 +22,115 @@ const (
 	CacheHit  = "cache hit"
 )
 
+const (
+	cacheFileExt = ".gz"
+
+	DefaultCacheMaxSize int64 = 512 << 20
+)
+
+var (
+	cacheMaxSize   = DefaultCacheMaxSize
+	cacheMaxSizeMu sync.Mutex
+)
+
+// SetCacheMaxSize sets the total on-disk size the cache may grow to before the
+// least recently used entries are evicted. A size <= 0 restores the default.
+func SetCacheMaxSize(size int64) {
+	cacheMaxSizeMu.Lock()
+	defer cacheMaxSizeMu.Unlock()
+
+	if size <= 0 {
+		size = DefaultCacheMaxSize
+	}
+	cacheMaxSize = size
+}
+
+func getCacheMaxSize() int64 {
+	cacheMaxSizeMu.Lock()
+	defer cacheMaxSizeMu.Unlock()
+	return cacheMaxSize
+}
+
+// cacheSizes tracks the total size of each cache root so that writes only walk
+// the cache when it may have outgrown the limit. A root is measured on its
+// first write and re-measured whenever eviction walks it.
+var (
+	cacheSizes   = make(map[string]int64)
+	cacheSizesMu sync.Mutex
+)
+
+// growCache adds delta to the tracked size of root and returns the new total.
+func growCache(root string, delta int64) (int64, error) {
+	cacheSizesMu.Lock()
+	defer cacheSizesMu.Unlock()
+
+	total, ok := cacheSizes[root]
+	if !ok {
+		// the walk already sees the write being accounted for
+		entries, err := listCacheRoot(root)
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			total += entry.Size
+		}
+		delta = 0
+	}
+	total += delta
+	cacheSizes[root] = total
+	return total, nil
+}
+
+func setCacheSize(root string, total int64) {
+	cacheSizesMu.Lock()
+	defer cacheSizesMu.Unlock()
+	cacheSizes[root] = total
+}
+
+// forgetCacheSize makes the next write measure root again.
+func forgetCacheSize(root string) {
+	cacheSizesMu.Lock()
+	defer cacheSizesMu.Unlock()
+	delete(cacheSizes, root)
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
 type FileCache[T any] struct {
-	dir string
+	root string
+	dir  string
+}
+
+func CacheRoot(dir string) string {
+	return filepath.Join(dir, ".tfsnap", "cache")
 }
 
 func GetCache[T any](dir, cacheType string) *FileCache[T] {
-	cacheDir := filepath.Join(filepath.Join(dir, ".tfsnap"), "cache", cacheType)
+	root := CacheRoot(dir)
+	cacheDir := filepath.Join(root, cacheType)
 
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		log.Printf("failed to create cache directory: %v", err)
 		return nil
 	}
 
-	return &FileCache[T]{dir: cacheDir}
+	return &FileCache[T]{root: root, dir: cacheDir}
 }
 
 func (c *FileCache[T]) Get(key string) (*T, error) {
-	data, err := os.ReadFile(filepath.Join(c.dir, key))
+	path := filepath.Join(c.dir, key+cacheFileExt)
+	data, err := readGzipFile(path)
+	if os.IsNotExist(err) {
+		// entries written before compression was introduced
+		path = filepath.Join(c.dir, key)
+		data, err = os.ReadFile(path)
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("%s: %w", CacheMiss, err)
@@ -42,18 +143,230 @@ func (c *FileCache[T]) Get(key string) (*T, error) {
 		return nil, fmt.Errorf("failed to unmarshal cache data: %w", err)
 	}
 
+	// the modification time doubles as the last access time for eviction
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		log.Printf("failed to touch cache file: %v", err)
+	}
+
 	return &value, nil
 }
 
 func (c *FileCache[T]) Set(key string, value T) error {
-	jsonData, err := json.MarshalIndent(value, "", "  ")
+	jsonData, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache value: %w", err)
 	}
 
-	if err := os.WriteFile(filepath.Join(c.dir, key), jsonData, 0644); err != nil {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(jsonData); err != nil {
+		return fmt.Errorf("failed to compress cache value: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress cache value: %w", err)
+	}
+
+	path := filepath.Join(c.dir, key+cacheFileExt)
+	legacyPath := filepath.Join(c.dir, key)
+	replaced := fileSize(path) + fileSize(legacyPath)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
+	if err := os.Remove(legacyPath); err != nil && !os.IsNotExist(err) {
+		log.Printf("failed to remove uncompressed cache file: %v", err)
+	}
+
+	total, err := growCache(c.root, int64(buf.Len())-replaced)
+	if err != nil {
+		log.Printf("failed to measure cache: %v", err)
+		return nil
+	}
+	if total > getCacheMaxSize() {
+		if _, _, err := evictCache(c.root, getCacheMaxSize(), path); err != nil {
+			log.Printf("failed to evict cache entries: %v", err)
+		}
+	}
 
 	return nil
 }
+
+func (c *FileCache[T]) Delete(key string) error {
+	defer forgetCacheSize(c.root)
+	for _, path := range []string{filepath.Join(c.dir, key+cacheFileExt), filepath.Join(c.dir, key)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete cache file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *FileCache[T]) Keys() ([]string, error) {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	keys := make([]string, 0, len(files))
+	for _, f := range files {
+		if !f.IsDir() {
+			keys = append(keys, strings.TrimSuffix(f.Name(), cacheFileExt))
+		}
+	}
+	return keys, nil
+}
+
+func readGzipFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
+
+type CacheEntry struct {
+	Type    string
+	Key     string
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// ListCacheEntries returns every cache entry under the working directory,
+// least recently used first.
+func ListCacheEntries(dir string) ([]CacheEntry, error) {
+	return listCacheRoot(CacheRoot(dir))
+}
+
+func listCacheRoot(root string) ([]CacheEntry, error) {
+	var entries []CacheEntry
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		cacheType, key := filepath.Split(rel)
+
+		entries = append(entries, CacheEntry{
+			Type:    filepath.Clean(cacheType),
+			Key:     strings.TrimSuffix(key, cacheFileExt),
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache entries: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime.Before(entries[j].ModTime)
+	})
+	return entries, nil
+}
+
+// ClearCache removes all entries of the given cache types, or the whole cache
+// when no type is given.
+func ClearCache(dir string, cacheTypes ...string) (int, error) {
+	entries, err := ListCacheEntries(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	defer forgetCacheSize(CacheRoot(dir))
+	removed := 0
+	for _, entry := range entries {
+		if len(cacheTypes) > 0 && !slices.Contains(cacheTypes, entry.Type) {
+			continue
+		}
+		if err := os.Remove(entry.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove cache entry %s: %w", entry.Path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// PruneCache removes entries not used within maxAge (if set) and then evicts
+// least recently used entries until the cache fits in maxSize.
+func PruneCache(dir string, maxAge time.Duration, maxSize int64) (int, int64, error) {
+	entries, err := ListCacheEntries(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	removed, freed := 0, int64(0)
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge)
+		for _, entry := range entries {
+			if entry.ModTime.After(cutoff) {
+				continue
+			}
+			if err := os.Remove(entry.Path); err != nil {
+				return removed, freed, fmt.Errorf("failed to remove cache entry %s: %w", entry.Path, err)
+			}
+			removed++
+			freed += entry.Size
+		}
+	}
+
+	n, size, err := evictCache(CacheRoot(dir), maxSize, "")
+	return removed + n, freed + size, err
+}
+
+// evictCache removes least recently used entries under root until the total
+// size is within maxSize. keep is never evicted. The measured total replaces
+// the tracked size of root.
+func evictCache(root string, maxSize int64, keep string) (int, int64, error) {
+	entries, err := listCacheRoot(root)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	removed, freed := 0, int64(0)
+	for _, entry := range entries {
+		if total <= maxSize {
+			break
+		}
+		if entry.Path == keep {
+			continue
+		}
+		if err := os.Remove(entry.Path); err != nil {
+			return removed, freed, fmt.Errorf("failed to evict cache entry %s: %w", entry.Path, err)
+		}
+		log.Printf("evicted cache entry %s/%s", entry.Type, entry.Key)
+		total -= entry.Size
+		removed++
+		freed += entry.Size
+	}
+	setCacheSize(root, total)
+	return removed, freed, nil
+}
@@ -0,0 +1,161 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileCacheCompressed(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := GetCache[string](tmpDir, "test")
+
+	value := strings.Repeat("provider schema ", 1000)
+	if err := cache.Set("schema", value); err != nil {
+		t.Fatalf("Cache.Set failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(CacheRoot(tmpDir), "test", "schema.gz"))
+	if err != nil {
+		t.Fatalf("Expected compressed cache file: %v", err)
+	}
+	if info.Size() >= int64(len(value)) {
+		t.Errorf("Expected compressed entry smaller than %d bytes, got %d", len(value), info.Size())
+	}
+}
+
+func TestFileCacheReadsLegacyEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := GetCache[string](tmpDir, "test")
+
+	if err := os.WriteFile(filepath.Join(CacheRoot(tmpDir), "test", "legacy"), []byte(`"old-value"`), 0644); err != nil {
+		t.Fatalf("Failed to write legacy entry: %v", err)
+	}
+
+	got, err := cache.Get("legacy")
+	if err != nil {
+		t.Fatalf("Cache.Get failed: %v", err)
+	}
+	if *got != "old-value" {
+		t.Errorf("Expected old-value, got %q", *got)
+	}
+}
+
+func TestFileCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := GetCache[string](tmpDir, "test")
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := cache.Set(key, key); err != nil {
+			t.Fatalf("Cache.Set failed: %v", err)
+		}
+	}
+
+	// make "a" the most recently used and "b" the least
+	old := time.Now().Add(-time.Hour)
+	for i, key := range []string{"b", "c", "a"} {
+		ts := old.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(filepath.Join(CacheRoot(tmpDir), "test", key+".gz"), ts, ts); err != nil {
+			t.Fatalf("Failed to set mtime: %v", err)
+		}
+	}
+	if _, err := cache.Get("a"); err != nil {
+		t.Fatalf("Cache.Get failed: %v", err)
+	}
+
+	entries, err := ListCacheEntries(tmpDir)
+	if err != nil {
+		t.Fatalf("ListCacheEntries failed: %v", err)
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	SetCacheMaxSize(total)
+	t.Cleanup(func() { SetCacheMaxSize(0) })
+
+	if err := cache.Set("d", "d"); err != nil {
+		t.Fatalf("Cache.Set failed: %v", err)
+	}
+
+	if _, err := cache.Get("b"); err == nil {
+		t.Error("Expected least recently used entry b to be evicted")
+	}
+	for _, key := range []string{"a", "d"} {
+		if _, err := cache.Get(key); err != nil {
+			t.Errorf("Expected entry %s to survive eviction: %v", key, err)
+		}
+	}
+}
+
+func TestPruneAndClearCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	schemas := GetCache[string](tmpDir, "provider_schema")
+	http := GetCache[string](tmpDir, "http")
+
+	for _, key := range []string{"old", "new"} {
+		if err := schemas.Set(key, key); err != nil {
+			t.Fatalf("Cache.Set failed: %v", err)
+		}
+	}
+	if err := http.Set("doc", "doc"); err != nil {
+		t.Fatalf("Cache.Set failed: %v", err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(CacheRoot(tmpDir), "provider_schema", "old.gz"), old, old); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+
+	removed, _, err := PruneCache(tmpDir, 24*time.Hour, DefaultCacheMaxSize)
+	if err != nil {
+		t.Fatalf("PruneCache failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 pruned entry, got %d", removed)
+	}
+
+	removed, err = ClearCache(tmpDir, "http")
+	if err != nil {
+		t.Fatalf("ClearCache failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 cleared entry, got %d", removed)
+	}
+
+	entries, err := ListCacheEntries(tmpDir)
+	if err != nil {
+		t.Fatalf("ListCacheEntries failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Type != "provider_schema" || entries[0].Key != "new" {
+		t.Errorf("Unexpected remaining entries: %+v", entries)
+	}
+}
+
+func TestFileCacheTracksSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	cache := GetCache[string](tmpDir, "test")
+
+	for _, value := range []string{"a", "b", "a much longer value than before"} {
+		for _, key := range []string{"x", "y"} {
+			if err := cache.Set(key, value); err != nil {
+				t.Fatalf("Cache.Set failed: %v", err)
+			}
+		}
+	}
+
+	entries, err := ListCacheEntries(tmpDir)
+	if err != nil {
+		t.Fatalf("ListCacheEntries failed: %v", err)
+	}
+	var want int64
+	for _, e := range entries {
+		want += e.Size
+	}
+	if got, _ := growCache(CacheRoot(tmpDir), 0); got != want {
+		t.Errorf("Expected tracked cache size %d, got %d", want, got)
+	}
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type credentialsFile struct {
+	Credentials map[string]struct {
+		Token string `json:"token"`
+	} `json:"credentials"`
+}
+
+// LookupCredentialsToken returns the API token Terraform would use for a
+// registry host, checking TF_TOKEN_<host> first and then the CLI credentials
+// file written by `terraform login`.
+func LookupCredentialsToken(host string) string {
+	if token := os.Getenv(credentialsEnvName(host)); token != "" {
+		return token
+	}
+
+	path := credentialsFilePath()
+	if path == "" {
+		return ""
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("failed to read credentials file: %v", err)
+		}
+		return ""
+	}
+
+	var creds credentialsFile
+	if err := json.Unmarshal(data, &creds); err != nil {
+		log.Printf("failed to parse credentials file: %v", err)
+		return ""
+	}
+
+	for credHost, cred := range creds.Credentials {
+		if strings.EqualFold(credHost, host) {
+			return cred.Token
+		}
+	}
+	return ""
+}
+
+func credentialsEnvName(host string) string {
+	name := strings.ReplaceAll(host, "-", "__")
+	name = strings.ReplaceAll(name, ".", "_")
+	return "TF_TOKEN_" + name
+}
+
+func credentialsFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".terraform.d", "credentials.tfrc.json")
+}
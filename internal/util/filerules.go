@@ -0,0 +1,190 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+const IgnoreFileName = ".tfsnapignore"
+
+// DefaultIncludePatterns are the files captured when no rules are configured.
+// Templates read by templatefile() are included; other files read by file(),
+// such as scripts or JSON policies, share extensions with files that aren't
+// configuration, so they are left to the config or .tfsnapignore.
+var DefaultIncludePatterns = []string{
+	"*.tf",
+	"*.tf.json",
+	"*.tfvars",
+	"*.tfvars.json",
+	"*.tftest.hcl",
+	"*.tfquery.hcl",
+	"*.tftpl",
+	"*.tpl",
+	".terraform.lock.hcl",
+}
+
+// skippedDirs hold tool state rather than configuration and are never walked.
+var skippedDirs = []string{".tfsnap", ".terraform", ".git"}
+
+// FileRules decides which files in a working directory belong to a snapshot.
+// A file is included when it matches an include pattern and no exclude
+// pattern. Patterns without a slash match the file name at any depth; other
+// patterns (including those starting with /) match the path relative to the
+// working directory, where ** spans any number of directories and a trailing
+// slash matches everything below.
+type FileRules struct {
+	include []string
+	exclude []string
+}
+
+func NewFileRules(include, exclude []string) *FileRules {
+	return &FileRules{include: include, exclude: exclude}
+}
+
+func DefaultFileRules() *FileRules {
+	return NewFileRules(DefaultIncludePatterns, nil)
+}
+
+// LoadFileRules combines the default patterns, the include and exclude
+// patterns from the config and the working directory's .tfsnapignore file.
+func LoadFileRules(cfg *config.Config) (*FileRules, error) {
+	include := append(append([]string{}, DefaultIncludePatterns...), cfg.Files.Include...)
+	exclude := append([]string{}, cfg.Files.Exclude...)
+
+	ignoreInclude, ignoreExclude, err := readIgnoreFile(filepath.Join(cfg.WorkingDirectory, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	include = append(include, ignoreInclude...)
+	exclude = append(exclude, ignoreExclude...)
+
+	return NewFileRules(include, exclude), nil
+}
+
+// readIgnoreFile parses a .tfsnapignore file. Each line is an exclude pattern;
+// lines starting with ! are include patterns. Blank lines and lines starting
+// with # are ignored.
+func readIgnoreFile(file string) ([]string, []string, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", IgnoreFileName, err)
+	}
+	defer f.Close()
+
+	var include, exclude []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if after, ok := strings.CutPrefix(line, "!"); ok {
+			include = append(include, after)
+		} else {
+			exclude = append(exclude, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	return include, exclude, nil
+}
+
+// Match reports whether the file at rel, relative to the working directory,
+// is part of a snapshot.
+func (r *FileRules) Match(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	return matchAny(r.include, rel) && !matchAny(r.exclude, rel)
+}
+
+// Files returns the matching files under dir as relative paths.
+func (r *FileRules) Files(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && isSkippedDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if r.Match(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func isSkippedDir(name string) bool {
+	for _, skipped := range skippedDirs {
+		if name == skipped {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(pattern, rel string) bool {
+	pattern = filepath.ToSlash(pattern)
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	if !anchored && !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
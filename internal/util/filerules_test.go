@@ -0,0 +1,126 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/phergul/tfsnap/internal/config"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.tf", "main.tf", true},
+		{"*.tf", "modules/vpc/main.tf", true},
+		{"*.tf", "main.tf.json", false},
+		{"fixtures/*.json", "fixtures/a.json", true},
+		{"fixtures/*.json", "fixtures/nested/a.json", false},
+		{"fixtures/**/*.json", "fixtures/nested/deep/a.json", true},
+		{"fixtures/**/*.json", "fixtures/a.json", true},
+		{"scripts/", "scripts/setup/run.sh", true},
+		{"/secret.auto.tfvars", "secret.auto.tfvars", true},
+		{"/secret.auto.tfvars", "env/secret.auto.tfvars", false},
+		{"**/secret.auto.tfvars", "env/secret.auto.tfvars", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultFileRules(t *testing.T) {
+	rules := DefaultFileRules()
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.tf", true},
+		{"modules/vpc/main.tf", true},
+		{"stack.tf.json", true},
+		{"dev.tfvars", true},
+		{"dev.tfvars.json", true},
+		{"tests/basic.tftest.hcl", true},
+		{"queries/list.tfquery.hcl", true},
+		{"templates/user_data.tftpl", true},
+		{"templates/policy.json.tpl", true},
+		{".terraform.lock.hcl", true},
+		{"scripts/init.sh", false},
+		{"fixtures/policy.json", false},
+		{"notes.txt", false},
+	}
+	for _, tt := range tests {
+		if got := rules.Match(tt.path); got != tt.want {
+			t.Errorf("default rules Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFileRules(t *testing.T) {
+	workDir := t.TempDir()
+	files := []string{
+		"main.tf",
+		"override.tf",
+		"stack.tf.json",
+		"tests/basic.tftest.hcl",
+		"secret.auto.tfvars",
+		"dev.tfvars",
+		".terraform.lock.hcl",
+		"fixtures/policy.json",
+		"scripts/init.sh",
+		"notes.txt",
+		".terraform/modules/vpc/main.tf",
+		".tfsnap/snapshots/a/tfconfig/main.tf",
+	}
+	for _, f := range files {
+		path := filepath.Join(workDir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", f, err)
+		}
+	}
+
+	ignore := "# local secrets\nsecret.auto.tfvars\n\n!scripts/*.sh\n"
+	if err := os.WriteFile(filepath.Join(workDir, IgnoreFileName), []byte(ignore), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	cfg := &config.Config{
+		WorkingDirectory: workDir,
+		Files: config.FilePatterns{
+			Include: []string{"fixtures/**/*.json"},
+			Exclude: []string{"dev.tfvars"},
+		},
+	}
+	rules, err := LoadFileRules(cfg)
+	if err != nil {
+		t.Fatalf("LoadFileRules failed: %v", err)
+	}
+
+	got, err := rules.Files(workDir)
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	sort.Strings(got)
+
+	want := []string{
+		".terraform.lock.hcl",
+		filepath.Join("fixtures", "policy.json"),
+		"main.tf",
+		"override.tf",
+		filepath.Join("scripts", "init.sh"),
+		"stack.tf.json",
+		filepath.Join("tests", "basic.tftest.hcl"),
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected files %v, got %v", want, got)
+	}
+}
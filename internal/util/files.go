@@ -6,7 +6,6 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
 func CopyFile(src, dst string) error {
@@ -26,33 +25,33 @@ func CopyFile(src, dst string) error {
 	return err
 }
 
-func CopyTFFiles(src, dst string, load bool) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
+// CopyTFFiles copies the files under src matched by rules to dst, keeping
+// their relative paths and file modes.
+func CopyTFFiles(src, dst string, rules *FileRules) error {
+	files, err := rules.Files(src)
+	if err != nil {
+		return err
+	}
 
-		if !load && strings.Contains(path, ".tfsnap") {
-			return nil
-		}
-		if info.IsDir() {
-			return nil
+	for _, rel := range files {
+		srcPath := filepath.Join(src, rel)
+		targetPath := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return err
 		}
-		if !(strings.HasSuffix(path, ".tf") || strings.HasSuffix(path, ".tfvars") || strings.HasSuffix(path, "terraform.lock.hcl")) {
-			return nil
+		if err := CopyFile(srcPath, targetPath); err != nil {
+			return err
 		}
 
-		rel, err := filepath.Rel(src, path)
+		info, err := os.Stat(srcPath)
 		if err != nil {
 			return err
 		}
-		targetPath := filepath.Join(dst, rel)
-		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		if err := os.Chmod(targetPath, info.Mode().Perm()); err != nil {
 			return err
 		}
-
-		return CopyFile(path, targetPath)
-	})
+	}
+	return nil
 }
 
 func HashFile(path string) (string, error) {
@@ -6,10 +6,24 @@ import (
 	"net/http"
 )
 
+var httpClient = http.DefaultClient
+
 func GetJson[T any](url string) (T, error) {
+	return GetJsonWithToken[T](url, "")
+}
+
+func GetJsonWithToken[T any](url, token string) (T, error) {
 	var result T
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return result, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return result, err
 	}
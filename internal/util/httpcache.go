@@ -0,0 +1,162 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Cache lifetimes for registry responses. Version lists and provider metadata
+// change whenever a release is published; documentation is tied to a provider
+// version and rarely changes once published.
+const (
+	VersionsTTL  = time.Hour
+	MetadataTTL  = time.Hour
+	DiscoveryTTL = 24 * time.Hour
+	DocsTTL      = 7 * 24 * time.Hour
+)
+
+var ErrOffline = errors.New("offline mode")
+
+type httpCacheEntry struct {
+	URL       string          `json:"url"`
+	ETag      string          `json:"etag,omitempty"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Body      json.RawMessage `json:"body"`
+}
+
+var (
+	httpCache   *FileCache[httpCacheEntry]
+	offlineMode bool
+	httpCacheMu sync.Mutex
+)
+
+// ConfigureHTTPCache enables the persistent response cache under the working
+// directory. In offline mode every request is served from the cache and a
+// miss is an error.
+func ConfigureHTTPCache(workingDir string, offline bool) {
+	httpCacheMu.Lock()
+	defer httpCacheMu.Unlock()
+
+	httpCache = GetCache[httpCacheEntry](workingDir, "http")
+	offlineMode = offline
+}
+
+func Offline() bool {
+	httpCacheMu.Lock()
+	defer httpCacheMu.Unlock()
+	return offlineMode
+}
+
+func httpCacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetJsonCached fetches url like GetJsonWithToken, serving responses younger
+// than ttl from the cache and revalidating older ones with their ETag. A stale
+// entry is still used when the registry cannot be reached.
+func GetJsonCached[T any](url, token string, ttl time.Duration) (T, error) {
+	var result T
+
+	httpCacheMu.Lock()
+	cache, offline := httpCache, offlineMode
+	httpCacheMu.Unlock()
+
+	if cache == nil {
+		if offline {
+			return result, fmt.Errorf("%w: no cache available for %s", ErrOffline, url)
+		}
+		return GetJsonWithToken[T](url, token)
+	}
+
+	key := httpCacheKey(url)
+	entry, err := cache.Get(key)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("failed to read http cache for %s: %v", url, err)
+	}
+	if err != nil {
+		entry = nil
+	}
+
+	if entry != nil && (offline || time.Since(entry.FetchedAt) < ttl) {
+		return decodeCacheEntry[T](entry)
+	}
+	if offline {
+		return result, fmt.Errorf("%w: %s is not cached; run `tfsnap cache warm` while online", ErrOffline, url)
+	}
+
+	fresh, err := fetchWithETag(url, token, entry)
+	if err != nil {
+		if entry != nil {
+			log.Printf("failed to revalidate %s, using stale cache entry: %v", url, err)
+			return decodeCacheEntry[T](entry)
+		}
+		return result, err
+	}
+
+	if err := cache.Set(key, *fresh); err != nil {
+		log.Printf("failed to cache response for %s: %v", url, err)
+	}
+	return decodeCacheEntry[T](fresh)
+}
+
+func fetchWithETag(url, token string, cached *httpCacheEntry) (*httpCacheEntry, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		log.Printf("%s not modified", url)
+		revalidated := *cached
+		revalidated.FetchedAt = time.Now()
+		return &revalidated, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("invalid json response from %s", url)
+	}
+
+	return &httpCacheEntry{
+		URL:       url,
+		ETag:      resp.Header.Get("ETag"),
+		FetchedAt: time.Now(),
+		Body:      body,
+	}, nil
+}
+
+func decodeCacheEntry[T any](entry *httpCacheEntry) (T, error) {
+	var result T
+	if err := json.Unmarshal(entry.Body, &result); err != nil {
+		return result, fmt.Errorf("failed to decode cached response for %s: %w", entry.URL, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type cachedPayload struct {
+	Value string `json:"value"`
+}
+
+func TestGetJsonCached(t *testing.T) {
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"value":"hello"}`))
+	}))
+	defer server.Close()
+
+	ConfigureHTTPCache(t.TempDir(), false)
+	t.Cleanup(func() { httpCache, offlineMode = nil, false })
+
+	got, err := GetJsonCached[cachedPayload](server.URL, "", time.Hour)
+	if err != nil {
+		t.Fatalf("GetJsonCached failed: %v", err)
+	}
+	if got.Value != "hello" {
+		t.Errorf("Expected hello, got %q", got.Value)
+	}
+
+	if _, err := GetJsonCached[cachedPayload](server.URL, "", time.Hour); err != nil {
+		t.Fatalf("GetJsonCached from cache failed: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected a fresh entry to be served from cache, got %d requests", requests)
+	}
+
+	got, err = GetJsonCached[cachedPayload](server.URL, "", 0)
+	if err != nil {
+		t.Fatalf("GetJsonCached revalidation failed: %v", err)
+	}
+	if got.Value != "hello" || notModified != 1 {
+		t.Errorf("Expected a 304 revalidation, got value %q and %d not-modified responses", got.Value, notModified)
+	}
+}
+
+func TestGetJsonCachedOffline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"value":"cached"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ConfigureHTTPCache(dir, false)
+	t.Cleanup(func() { httpCache, offlineMode = nil, false })
+
+	if _, err := GetJsonCached[cachedPayload](server.URL+"/a", "", time.Hour); err != nil {
+		t.Fatalf("GetJsonCached failed: %v", err)
+	}
+	server.Close()
+
+	ConfigureHTTPCache(dir, true)
+
+	got, err := GetJsonCached[cachedPayload](server.URL+"/a", "", 0)
+	if err != nil {
+		t.Fatalf("Expected stale entry to be served offline: %v", err)
+	}
+	if got.Value != "cached" {
+		t.Errorf("Expected cached, got %q", got.Value)
+	}
+
+	if _, err := GetJsonCached[cachedPayload](server.URL+"/b", "", time.Hour); !errors.Is(err, ErrOffline) {
+		t.Errorf("Expected ErrOffline on cache miss, got %v", err)
+	}
+}
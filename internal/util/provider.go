@@ -3,6 +3,8 @@ package util
 import (
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/phergul/tfsnap/internal/config"
@@ -26,11 +28,15 @@ type ProviderMetadata struct {
 }
 
 func GetAvailableProviderVersions(registrySource string) ([]string, error) {
-	url := fmt.Sprintf("https://registry.terraform.io/v1/providers/%s/versions", registrySource)
+	reg, src, err := RegistryForSource(registrySource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider versions: %w", err)
+	}
 
-	versions, err := GetJson[VersionResponse](url)
+	versionsURL := reg.ProviderURL(src.ProviderID(), "versions")
+	versions, err := GetJsonCached[VersionResponse](versionsURL, reg.TokenFor(versionsURL), VersionsTTL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get provider versions: %s", err)
+		return nil, fmt.Errorf("failed to get provider versions: %w", err)
 	}
 
 	var versionList []string
@@ -65,12 +71,38 @@ func GetLatestProviderVersion(cfg *config.Config) string {
 }
 
 func GetProviderRegistryMeta(registrySource string) (ProviderMetadata, error) {
-	url := fmt.Sprintf("https://registry.terraform.io/v1/providers/%s", registrySource)
+	reg, src, err := RegistryForSource(registrySource)
+	if err != nil {
+		return ProviderMetadata{}, fmt.Errorf("error getting provider repo: %w", err)
+	}
 
-	meta, err := GetJson[ProviderMetadata](url)
+	metaURL := reg.ProviderURL(src.ProviderID())
+	meta, err := GetJsonCached[ProviderMetadata](metaURL, reg.TokenFor(metaURL), MetadataTTL)
 	if err != nil {
 		return ProviderMetadata{}, fmt.Errorf("error getting provider repo: %w", err)
 	}
 
 	return meta, nil
 }
+
+// FindProviderBinary locates the locally built provider binary in the
+// configured provider directory.
+func FindProviderBinary(cfg *config.Config) (string, error) {
+	if cfg.Provider.ProviderDirectory == "" {
+		return "", fmt.Errorf("provider directory not configured")
+	}
+
+	possiblePaths := []string{
+		filepath.Join(cfg.Provider.ProviderDirectory, "terraform-provider-"+cfg.Provider.Name),
+		filepath.Join(cfg.Provider.ProviderDirectory, "bin", "terraform-provider-"+cfg.Provider.Name),
+		filepath.Join(cfg.Provider.ProviderDirectory, "dist", "terraform-provider-"+cfg.Provider.Name),
+	}
+
+	for _, path := range possiblePaths {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("provider binary not found in %s", cfg.Provider.ProviderDirectory)
+}
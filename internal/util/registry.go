@@ -0,0 +1,133 @@
+package util
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const DefaultRegistryHost = "registry.terraform.io"
+
+// RegistrySource is a provider source address split into its parts. Sources
+// without a hostname belong to the default public registry.
+type RegistrySource struct {
+	Host      string
+	Namespace string
+	Name      string
+}
+
+func ParseRegistrySource(source string) (RegistrySource, error) {
+	parts := strings.Split(strings.Trim(source, "/"), "/")
+	switch len(parts) {
+	case 2:
+		return RegistrySource{Host: DefaultRegistryHost, Namespace: parts[0], Name: parts[1]}, nil
+	case 3:
+		return RegistrySource{Host: strings.ToLower(parts[0]), Namespace: parts[1], Name: parts[2]}, nil
+	}
+	return RegistrySource{}, fmt.Errorf("invalid provider source %q", source)
+}
+
+func (s RegistrySource) ProviderID() string {
+	return s.Namespace + "/" + s.Name
+}
+
+func (s RegistrySource) String() string {
+	return s.Host + "/" + s.ProviderID()
+}
+
+// Registry is a provider registry host resolved through Terraform's service
+// discovery protocol.
+type Registry struct {
+	Host         string
+	BaseURL      string
+	ProvidersURL string
+	Token        string
+}
+
+type discoveryResponse struct {
+	ProvidersV1 string `json:"providers.v1"`
+}
+
+var (
+	registries   = make(map[string]*Registry)
+	registriesMu sync.Mutex
+)
+
+// DiscoverRegistry resolves the providers API of a registry host from its
+// /.well-known/terraform.json document. Results are kept for the lifetime of
+// the process.
+func DiscoverRegistry(host string) (*Registry, error) {
+	host = strings.ToLower(host)
+
+	registriesMu.Lock()
+	defer registriesMu.Unlock()
+
+	if reg, ok := registries[host]; ok {
+		return reg, nil
+	}
+
+	baseURL := "https://" + host
+	token := LookupCredentialsToken(host)
+
+	discovery, err := GetJsonCached[discoveryResponse](baseURL+"/.well-known/terraform.json", token, DiscoveryTTL)
+	if err != nil {
+		return nil, fmt.Errorf("service discovery failed for %s: %w", host, err)
+	}
+	if discovery.ProvidersV1 == "" {
+		return nil, fmt.Errorf("registry %s does not support the providers.v1 protocol", host)
+	}
+
+	base, err := url.Parse(baseURL + "/.well-known/terraform.json")
+	if err != nil {
+		return nil, err
+	}
+	providersRef, err := url.Parse(discovery.ProvidersV1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid providers.v1 url %q: %w", discovery.ProvidersV1, err)
+	}
+	providersURL := base.ResolveReference(providersRef).String()
+	if !strings.HasSuffix(providersURL, "/") {
+		providersURL += "/"
+	}
+	log.Printf("Discovered providers API for %s at %s", host, providersURL)
+
+	reg := &Registry{
+		Host:         host,
+		BaseURL:      baseURL,
+		ProvidersURL: providersURL,
+		Token:        token,
+	}
+	registries[host] = reg
+	return reg, nil
+}
+
+// RegistryForSource parses a provider source and discovers its registry.
+func RegistryForSource(source string) (*Registry, RegistrySource, error) {
+	src, err := ParseRegistrySource(source)
+	if err != nil {
+		return nil, src, err
+	}
+
+	reg, err := DiscoverRegistry(src.Host)
+	if err != nil {
+		return nil, src, err
+	}
+	return reg, src, nil
+}
+
+// TokenFor returns the registry token if rawURL is on the registry host.
+// Discovery may point the API at another host, which must not receive the
+// credentials issued for this one.
+func (r *Registry) TokenFor(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || !strings.EqualFold(u.Host, r.Host) {
+		return ""
+	}
+	return r.Token
+}
+
+func (r *Registry) ProviderURL(providerID string, parts ...string) string {
+	return r.ProvidersURL + strings.Join(append([]string{providerID}, parts...), "/")
+}
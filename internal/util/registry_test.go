@@ -0,0 +1,186 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestRegistry(t *testing.T, token string) string {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"providers.v1": "/api/providers/"})
+	})
+	mux.HandleFunc("/api/providers/acme/widget/versions", func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"versions":[{"version":"1.2.0"},{"version":"1.10.0"},{"version":"0.9.1"}]}`))
+	})
+	mux.HandleFunc("/api/providers/acme/widget", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"acme/widget/1.10.0","namespace":"acme","name":"widget","version":"1.10.0"}`))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	origClient := httpClient
+	httpClient = server.Client()
+	t.Cleanup(func() { httpClient = origClient })
+
+	registriesMu.Lock()
+	registries = make(map[string]*Registry)
+	registriesMu.Unlock()
+
+	return strings.TrimPrefix(server.URL, "https://")
+}
+
+func TestParseRegistrySource(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    RegistrySource
+		wantErr bool
+	}{
+		{source: "hashicorp/aws", want: RegistrySource{Host: DefaultRegistryHost, Namespace: "hashicorp", Name: "aws"}},
+		{source: "registry.opentofu.org/hashicorp/aws", want: RegistrySource{Host: "registry.opentofu.org", Namespace: "hashicorp", Name: "aws"}},
+		{source: "App.Terraform.io/org/custom", want: RegistrySource{Host: "app.terraform.io", Namespace: "org", Name: "custom"}},
+		{source: "aws", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := ParseRegistrySource(tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRegistrySource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRegistrySource() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAvailableProviderVersionsCustomRegistry(t *testing.T) {
+	host := newTestRegistry(t, "")
+
+	versions, err := GetAvailableProviderVersions(host + "/acme/widget")
+	if err != nil {
+		t.Fatalf("GetAvailableProviderVersions failed: %v", err)
+	}
+
+	expected := []string{"v1.10.0", "v1.2.0", "v0.9.1"}
+	if strings.Join(versions, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, versions)
+	}
+
+	meta, err := GetProviderRegistryMeta(host + "/acme/widget")
+	if err != nil {
+		t.Fatalf("GetProviderRegistryMeta failed: %v", err)
+	}
+	if meta.Name != "widget" || meta.Namespace != "acme" {
+		t.Errorf("Unexpected metadata: %+v", meta)
+	}
+}
+
+func TestGetAvailableProviderVersionsWithCredentials(t *testing.T) {
+	token := "secret-token"
+	host := newTestRegistry(t, token)
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if _, err := GetAvailableProviderVersions(host + "/acme/widget"); err == nil {
+		t.Fatal("Expected unauthorized error without credentials")
+	}
+
+	registriesMu.Lock()
+	registries = make(map[string]*Registry)
+	registriesMu.Unlock()
+
+	creds := `{"credentials":{"` + host + `":{"token":"` + token + `"}}}`
+	credPath := filepath.Join(home, ".terraform.d", "credentials.tfrc.json")
+	if err := os.MkdirAll(filepath.Dir(credPath), 0755); err != nil {
+		t.Fatalf("Failed to create credentials dir: %v", err)
+	}
+	if err := os.WriteFile(credPath, []byte(creds), 0600); err != nil {
+		t.Fatalf("Failed to write credentials file: %v", err)
+	}
+
+	if _, err := GetAvailableProviderVersions(host + "/acme/widget"); err != nil {
+		t.Fatalf("GetAvailableProviderVersions with credentials failed: %v", err)
+	}
+}
+
+func TestRegistryTokenScopedToHost(t *testing.T) {
+	// the providers API is served from a different host than the registry
+	var gotAuth []string
+	api := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"versions":[{"version":"1.0.0"}]}`))
+	}))
+	t.Cleanup(api.Close)
+
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"providers.v1": api.URL + "/v1/providers/"})
+	}))
+	t.Cleanup(registry.Close)
+
+	origClient := httpClient
+	httpClient = registry.Client()
+	t.Cleanup(func() { httpClient = origClient })
+	registriesMu.Lock()
+	registries = make(map[string]*Registry)
+	registriesMu.Unlock()
+
+	host := strings.TrimPrefix(registry.URL, "https://")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	credPath := filepath.Join(home, ".terraform.d", "credentials.tfrc.json")
+	if err := os.MkdirAll(filepath.Dir(credPath), 0755); err != nil {
+		t.Fatalf("Failed to create credentials dir: %v", err)
+	}
+	creds := `{"credentials":{"` + host + `":{"token":"secret-token"}}}`
+	if err := os.WriteFile(credPath, []byte(creds), 0600); err != nil {
+		t.Fatalf("Failed to write credentials file: %v", err)
+	}
+
+	reg, err := DiscoverRegistry(host)
+	if err != nil {
+		t.Fatalf("DiscoverRegistry failed: %v", err)
+	}
+	if reg.Token != "secret-token" {
+		t.Fatalf("Expected the registry token to be found, got %q", reg.Token)
+	}
+	if got := reg.TokenFor(registry.URL + "/v2/provider-docs/1"); got != "secret-token" {
+		t.Errorf("Expected the token for the registry host, got %q", got)
+	}
+
+	if _, err := GetAvailableProviderVersions(host + "/acme/widget"); err != nil {
+		t.Fatalf("GetAvailableProviderVersions failed: %v", err)
+	}
+	if len(gotAuth) != 1 || gotAuth[0] != "" {
+		t.Errorf("Expected no Authorization header on the API host, got %q", gotAuth)
+	}
+}
+
+func TestLookupCredentialsTokenEnv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TF_TOKEN_app_terraform_io", "env-token")
+	t.Setenv("TF_TOKEN_my__registry_example_com", "dashed-token")
+
+	if got := LookupCredentialsToken("app.terraform.io"); got != "env-token" {
+		t.Errorf("Expected env-token, got %q", got)
+	}
+	if got := LookupCredentialsToken("my-registry.example.com"); got != "dashed-token" {
+		t.Errorf("Expected dashed-token, got %q", got)
+	}
+	if got := LookupCredentialsToken("unknown.example.com"); got != "" {
+		t.Errorf("Expected no token, got %q", got)
+	}
+}
@@ -119,7 +119,7 @@ func TestCopyTFFiles(t *testing.T) {
 		}
 	}
 
-	err := CopyTFFiles(srcDir, dstDir, false)
+	err := CopyTFFiles(srcDir, dstDir, DefaultFileRules())
 	if err != nil {
 		t.Fatalf("CopyTFFiles failed: %v", err)
 	}
@@ -218,3 +218,36 @@ func TestFileCacheGetMissing(t *testing.T) {
 		t.Error("Cache.Get should return error for missing key")
 	}
 }
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := UnifiedDiff("a", "b", "same\n", "same\n"); diff != "" {
+		t.Errorf("Expected no diff for equal input, got %q", diff)
+	}
+
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\n3\nfour\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	expected := `--- a
++++ b
+@@ -1,7 +1,7 @@
+ 1
+ 2
+ 3
+-4
++four
+ 5
+ 6
+ 7
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	if diff := UnifiedDiff("a", "b", a, b); diff != expected {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+
+	if diff := UnifiedDiff("a", "b", "", "new\n"); diff != "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n" {
+		t.Errorf("Unexpected diff for new file:\n%s", diff)
+	}
+}